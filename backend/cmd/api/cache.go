@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"log/slog"
+)
+
+// onModelInvalidateCache tell the event services to reload the triggers,
+// conditions and shareds of the organization, the change is already saved
+// so a failing publish is only logged
+func (app *application) onModelInvalidateCache(e *core.ModelEvent) error {
+	record, _ := e.Model.(*models.Record)
+
+	organizationId := record.GetString("organization")
+
+	// a condition belong to the organization of its trigger, a condition
+	// deleted with its trigger is covered by the trigger itself
+	if record.Collection().Name == "trigger_conditions" {
+		trigger, err := app.pb.Dao().FindRecordById("triggers", record.GetString("trigger"))
+		if err != nil {
+			return nil
+		}
+		organizationId = trigger.GetString("organization")
+	}
+
+	if err := app.realtime.PublishCacheInvalidation(organizationId); err != nil {
+		app.pb.Logger().Error(
+			"unable to invalidate the event cache",
+			slog.String("organization", organizationId),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return nil
+}
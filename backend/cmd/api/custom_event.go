@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"regexp"
+	"strings"
+)
+
+const ManualEmitterCode = "manual"
+
+var customEventVariableRX = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+func (app *application) GetCustomEventByOrganizationIdAndCustomEventId(organizationId, customEventId string) (*models.Record, error) {
+	return app.pb.Dao().FindFirstRecordByFilter(
+		"custom_events",
+		"organization = {:organizationId} && id = {:customEventId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"customEventId":  customEventId,
+		},
+	)
+}
+
+// RenderCustomEventPayload replace the {{variable}} of the stored payload then
+// apply overrides as a json merge patch (a null value remove the field)
+func RenderCustomEventPayload(payload json.RawMessage, variables map[string]any, overrides json.RawMessage) (json.RawMessage, error) {
+	var value any
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	value, err := renderTemplate(value, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overrides) > 0 {
+		var patch any
+		if err := json.Unmarshal(overrides, &patch); err != nil {
+			return nil, err
+		}
+		value = mergePatch(value, patch)
+	}
+
+	return json.Marshal(value)
+}
+
+func renderTemplate(value any, variables map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		// a string that is only a variable keep the variable type
+		if match := customEventVariableRX.FindStringSubmatch(v); match != nil && match[0] == strings.TrimSpace(v) {
+			variable, ok := variables[match[1]]
+			if !ok {
+				return nil, fmt.Errorf("missing variable %s", match[1])
+			}
+			return variable, nil
+		}
+
+		var err error
+		rendered := customEventVariableRX.ReplaceAllStringFunc(v, func(s string) string {
+			name := customEventVariableRX.FindStringSubmatch(s)[1]
+			variable, ok := variables[name]
+			if !ok {
+				err = fmt.Errorf("missing variable %s", name)
+				return s
+			}
+			if str, ok := variable.(string); ok {
+				return str
+			}
+			data, _ := json.Marshal(variable)
+			return string(data)
+		})
+		return rendered, err
+
+	case map[string]any:
+		for key, item := range v {
+			rendered, err := renderTemplate(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+		return v, nil
+
+	case []any:
+		for i, item := range v {
+			rendered, err := renderTemplate(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+		return v, nil
+	}
+
+	return value, nil
+}
+
+// mergePatch follow RFC 7386
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
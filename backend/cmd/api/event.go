@@ -2,9 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"github.com/google/uuid"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"time"
 )
 
@@ -12,7 +14,8 @@ type EventName struct {
 	Name string `db:"name" json:"name"`
 }
 
-func (app *application) CreateEvent(organizationID string, name string, payload json.RawMessage, emitterCode, emitterName string) (*models.Record, error) {
+// CreateEvent save an event, emittedBy is the user behind it if any
+func (app *application) CreateEvent(organizationID string, name string, payload json.RawMessage, emitterCode, emitterName, emittedBy string) (*models.Record, error) {
 	collection, err := app.pb.Dao().FindCollectionByNameOrId("events")
 	if err != nil {
 		return nil, err
@@ -26,6 +29,7 @@ func (app *application) CreateEvent(organizationID string, name string, payload
 	record.Set("emitter_code", emitterCode)
 	record.Set("emitter_name", emitterName)
 	record.Set("emitted_at", time.Now().Format(time.RFC3339Nano))
+	record.Set("emitted_by", emittedBy)
 
 	if err := app.pb.Dao().SaveRecord(record); err != nil {
 		return nil, err
@@ -50,6 +54,32 @@ func (app *application) GetAvailableEventNames(organizationId string) ([]*EventN
 	return eventsNames, err
 }
 
+type EventSchema struct {
+	Name        string        `db:"name" json:"name"`
+	Description string        `db:"description" json:"description"`
+	Schema      types.JsonRaw `db:"schema" json:"schema"`
+	Mode        string        `db:"mode" json:"mode"`
+}
+
+func (app *application) GetEventSchemas(organizationId string) ([]*EventSchema, error) {
+	var schemas []*EventSchema
+
+	err := app.pb.Dao().DB().
+		Select(
+			"name",
+			"description",
+			"schema",
+			"mode",
+		).
+		From("event_schemas").
+		Where(dbx.HashExp{
+			"organization": organizationId,
+		}).
+		OrderBy("name").
+		All(&schemas)
+	return schemas, err
+}
+
 func (app *application) onCreateEvent(e *core.ModelEvent) error {
 	record, _ := e.Model.(*models.Record)
 	msgJson, err := record.MarshalJSON()
@@ -57,8 +87,44 @@ func (app *application) onCreateEvent(e *core.ModelEvent) error {
 		return err
 	}
 
-	if err := app.realtime.Publish("events", msgJson); err != nil {
+	if err := app.realtime.PublishEvent(record.Id, msgJson); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (app *application) GetEventByOrganizationIdAndEventId(organizationId, eventId string) (*models.Record, error) {
+	return app.pb.Dao().FindFirstRecordByFilter(
+		"events",
+		"organization = {:organizationId} && id = {:eventId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"eventId":        eventId,
+		},
+	)
+}
+
+// ReplayEvent publish a stored event again, optionally for a single trigger
+// and in dry run mode, the event service link the new processes to the old ones
+func (app *application) ReplayEvent(record *models.Record, triggerId string, dryRun bool) (string, error) {
+	replayId := uuid.NewString()
+
+	data := record.PublicExport()
+	data["replay"] = map[string]any{
+		"id":      replayId,
+		"trigger": triggerId,
+		"dry_run": dryRun,
+	}
+
+	msgJson, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	// the event id is still in the JetStream duplicate window, use the replay one
+	if err := app.realtime.PublishEvent(replayId, msgJson); err != nil {
+		return "", err
+	}
+
+	return replayId, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"github.com/evntboard/app/backend/internal/schema"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// onBeforeSaveEventSchema refuse a schema the module service could not compile
+func (app *application) onBeforeSaveEventSchema(e *core.ModelEvent) error {
+	record, _ := e.Model.(*models.Record)
+
+	if _, err := schema.Compile([]byte(record.GetString("schema"))); err != nil {
+		return fmt.Errorf("invalid json schema : %s", err.Error())
+	}
+
+	return nil
+}
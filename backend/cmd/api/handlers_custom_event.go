@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+)
+
+type EmitCustomEventBody struct {
+	Variables map[string]any  `json:"variables"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (app *application) postEmitCustomEvent(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	customEventId := c.PathParam("customEventId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body EmitCustomEventBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	customEvent, err := app.GetCustomEventByOrganizationIdAndCustomEventId(organizationId, customEventId)
+	if err != nil || customEvent == nil {
+		return apis.NewApiError(404, "custom event not found ...", nil)
+	}
+
+	payload, err := RenderCustomEventPayload(
+		json.RawMessage(customEvent.GetString("payload")),
+		body.Variables,
+		body.Payload,
+	)
+	if err != nil {
+		return apis.NewApiError(400, err.Error(), nil)
+	}
+
+	event, err := app.CreateEvent(
+		organizationId,
+		customEvent.GetString("name"),
+		payload,
+		ManualEmitterCode,
+		info.AuthRecord.Username(),
+		info.AuthRecord.Id,
+	)
+	if err != nil {
+		return apis.NewApiError(500, "can't emit custom event ...", nil)
+	}
+
+	return c.JSON(200, event)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/evntboard/app/backend/internal/pattern"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
@@ -45,11 +46,116 @@ func (app *application) getAvailableEventNames(c echo.Context) error {
 		}
 	}
 
+	// a pattern is not a name an event can be emitted with
 	for _, condition := range conditionNames {
-		if !slices.Contains(names, condition.Name) {
+		if pattern.IsExactName(condition.Name) && !slices.Contains(names, condition.Name) {
 			names = append(names, condition.Name)
 		}
 	}
 
+	schemas, err := app.GetEventSchemas(organizationId)
+
+	if err != nil {
+		return apis.NewApiError(400, "error when trying to get event schemas ...", nil)
+	}
+
+	for _, schema := range schemas {
+		if !slices.Contains(names, schema.Name) {
+			names = append(names, schema.Name)
+		}
+	}
+
 	return c.JSON(200, names)
 }
+
+func (app *application) getEventSchemas(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can access this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && role != null",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	schemas, err := app.GetEventSchemas(organizationId)
+
+	if err != nil {
+		return apis.NewApiError(400, "error when trying to get event schemas ...", nil)
+	}
+
+	// keyed by event name, the editor look up the payload shape of a condition name
+	result := make(map[string]*EventSchema, len(schemas))
+	for _, schema := range schemas {
+		result[schema.Name] = schema
+	}
+
+	return c.JSON(200, result)
+}
+
+type ReplayEventBody struct {
+	Trigger string `json:"trigger"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+func (app *application) postReplayEvent(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	eventId := c.PathParam("eventId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body ReplayEventBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	event, err := app.GetEventByOrganizationIdAndEventId(organizationId, eventId)
+	if err != nil || event == nil {
+		return apis.NewApiError(404, "event not found ...", nil)
+	}
+
+	if body.Trigger != "" {
+		trigger, err := app.pb.Dao().FindFirstRecordByFilter(
+			"triggers",
+			"organization = {:organizationId} && id = {:triggerId}",
+			dbx.Params{
+				"organizationId": organizationId,
+				"triggerId":      body.Trigger,
+			},
+		)
+
+		if err != nil || trigger == nil {
+			return apis.NewApiError(404, "trigger not found ...", nil)
+		}
+	}
+
+	replayId, err := app.ReplayEvent(event, body.Trigger, body.DryRun)
+	if err != nil {
+		return apis.NewApiError(500, "can't replay event ...", nil)
+	}
+
+	return c.JSON(200, map[string]any{
+		"replay": replayId,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"net/http"
 	"strings"
 )
@@ -71,20 +72,25 @@ func (app *application) getExport(c echo.Context) error {
 
 		for _, triggerRecord := range triggerRecords {
 			triggerExport := ExportTrigger{
-				Entity:     "trigger",
-				Code:       triggerRecord.GetString("code"),
-				Name:       triggerRecord.GetString("name"),
-				Channel:    triggerRecord.GetString("channel"),
-				Conditions: make([]ExportTriggerCondition, 0),
+				Entity:           "trigger",
+				Code:             triggerRecord.GetString("code"),
+				Language:         triggerRecord.GetString("language"),
+				Name:             triggerRecord.GetString("name"),
+				Channel:          triggerRecord.GetString("channel"),
+				MaxExecutionTime: int32(triggerRecord.GetInt("max_execution_time")),
+				Conditions:       make([]ExportTriggerCondition, 0),
 			}
 
 			for _, cRecord := range conditionsRecords {
 				if cRecord.GetString("trigger") == triggerRecord.Id {
 					triggerExport.Conditions = append(triggerExport.Conditions, ExportTriggerCondition{
-						Code:    cRecord.GetString("code"),
-						Name:    cRecord.GetString("name"),
-						Type:    cRecord.GetString("type"),
-						Timeout: int32(cRecord.GetInt("timeout")),
+						Code:             cRecord.GetString("code"),
+						Language:         cRecord.GetString("language"),
+						Name:             cRecord.GetString("name"),
+						Type:             cRecord.GetString("type"),
+						Timeout:          int32(cRecord.GetInt("timeout")),
+						MaxExecutionTime: int32(cRecord.GetInt("max_execution_time")),
+						Matcher:          types.JsonRaw(cRecord.GetString("matcher")),
 					})
 				}
 			}
@@ -93,9 +99,10 @@ func (app *application) getExport(c echo.Context) error {
 
 		for _, sharedRecord := range sharedRecords {
 			datas = append(datas, ExportShared{
-				Entity: "shared",
-				Code:   sharedRecord.GetString("code"),
-				Name:   sharedRecord.GetString("name"),
+				Entity:   "shared",
+				Code:     sharedRecord.GetString("code"),
+				Language: sharedRecord.GetString("language"),
+				Name:     sharedRecord.GetString("name"),
 			})
 		}
 
@@ -137,19 +144,24 @@ func (app *application) getExport(c echo.Context) error {
 
 	if triggerRecord != nil {
 		triggerExport := ExportTrigger{
-			Entity:     "trigger",
-			Code:       triggerRecord.GetString("code"),
-			Name:       triggerRecord.GetString("name"),
-			Channel:    triggerRecord.GetString("channel"),
-			Conditions: make([]ExportTriggerCondition, 0),
+			Entity:           "trigger",
+			Code:             triggerRecord.GetString("code"),
+			Language:         triggerRecord.GetString("language"),
+			Name:             triggerRecord.GetString("name"),
+			Channel:          triggerRecord.GetString("channel"),
+			MaxExecutionTime: int32(triggerRecord.GetInt("max_execution_time")),
+			Conditions:       make([]ExportTriggerCondition, 0),
 		}
 
 		for _, cRecord := range conditionsRecords {
 			triggerExport.Conditions = append(triggerExport.Conditions, ExportTriggerCondition{
-				Code:    cRecord.GetString("code"),
-				Name:    cRecord.GetString("name"),
-				Type:    cRecord.GetString("type"),
-				Timeout: int32(cRecord.GetInt("timeout")),
+				Code:             cRecord.GetString("code"),
+				Language:         cRecord.GetString("language"),
+				Name:             cRecord.GetString("name"),
+				Type:             cRecord.GetString("type"),
+				Timeout:          int32(cRecord.GetInt("timeout")),
+				MaxExecutionTime: int32(cRecord.GetInt("max_execution_time")),
+				Matcher:          types.JsonRaw(cRecord.GetString("matcher")),
 			})
 		}
 
@@ -158,9 +170,10 @@ func (app *application) getExport(c echo.Context) error {
 
 	if sharedRecord != nil {
 		datas = append(datas, ExportTrigger{
-			Entity: "shared",
-			Code:   sharedRecord.GetString("code"),
-			Name:   sharedRecord.GetString("name"),
+			Entity:   "shared",
+			Code:     sharedRecord.GetString("code"),
+			Language: sharedRecord.GetString("language"),
+			Name:     sharedRecord.GetString("name"),
 		})
 	}
 
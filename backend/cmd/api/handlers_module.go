@@ -1,11 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
-	"time"
+	"github.com/pocketbase/pocketbase/models"
+	"slices"
 )
 
 func (app *application) deleteEjectModule(c echo.Context) error {
@@ -28,23 +28,54 @@ func (app *application) deleteEjectModule(c echo.Context) error {
 	}
 
 	module, err := app.GetModuleByOrganizationIdAndModuleId(organizationId, moduleId)
-
-	msgJson, err := json.Marshal(map[string]any{
-		"type":   "module",
-		"action": "eject",
-	})
 	if err != nil || module == nil {
 		return apis.NewApiError(404, "no module found ...", nil)
 	}
 
-	_, err = app.realtime.Request(app.realtime.GetChannelForModule(module.GetString("session")), msgJson, time.Second*3)
-
-	// module is not connect but session is set
+	instances, err := app.GetModuleInstances(module.Id)
 	if err != nil {
-		if err := app.ResetModuleSession(moduleId); err != nil {
-			return apis.NewApiError(500, "can't reset module session ...", nil)
-		}
+		return apis.NewApiError(500, "can't find module instances ...", nil)
+	}
+
+	// ?session= eject a single instance, else all of them
+	if session := c.QueryParam("session"); session != "" {
+		instances = slices.DeleteFunc(instances, func(instance *models.Record) bool {
+			return instance.GetString("session") != session
+		})
+	}
+
+	if err := app.EjectModuleInstances(module.Id, instances, "ejected"); err != nil {
+		return apis.NewApiError(500, "can't reset module session ...", nil)
 	}
 
 	return c.JSON(200, nil)
 }
+
+func (app *application) getModuleManifest(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	moduleId := c.PathParam("moduleId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can access this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && role != null",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	module, err := app.GetModuleByOrganizationIdAndId(organizationId, moduleId)
+
+	if err != nil || module == nil {
+		return apis.NewApiError(404, "no module found ...", nil)
+	}
+
+	// null until the module register with a manifest
+	return c.JSON(200, module.Get("manifest"))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"github.com/evntboard/app/backend/internal/moduletoken"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"time"
+)
+
+type CreateModuleTokenBody struct {
+	Label string `json:"label"`
+	// rpc methods the token can call, empty for all of them
+	Methods []string `json:"methods"`
+	// event name patterns the token can emit, empty for all of them
+	Events    []string `json:"events"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+type RotateModuleTokenBody struct {
+	// seconds during which the previous token is still accepted
+	GracePeriod *int `json:"grace_period"`
+}
+
+func (app *application) postModuleToken(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	moduleId := c.PathParam("moduleId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body CreateModuleTokenBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	if err := moduletoken.ValidateScopes(body.Methods, body.Events); err != nil {
+		return apis.NewApiError(400, "invalid token scopes ...", map[string]any{"error": err.Error()})
+	}
+
+	if body.ExpiresAt != "" {
+		expiresAt, err := types.ParseDateTime(body.ExpiresAt)
+		if err != nil || expiresAt.IsZero() || !expiresAt.Time().After(time.Now()) {
+			return apis.NewApiError(400, "invalid token expiry ...", nil)
+		}
+	}
+
+	module, err := app.GetModuleByOrganizationIdAndId(organizationId, moduleId)
+	if err != nil || module == nil {
+		return apis.NewApiError(404, "no module found ...", nil)
+	}
+
+	token, created, err := app.CreateModuleToken(module.Id, body.Label, body.Methods, body.Events, body.ExpiresAt)
+	if err != nil {
+		return apis.NewApiError(500, "can't create module token ...", nil)
+	}
+
+	// the plain token is never shown again
+	return c.JSON(200, map[string]any{
+		"token":  token,
+		"record": moduleTokenJSON(created),
+	})
+}
+
+func (app *application) postRotateModuleToken(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	moduleId := c.PathParam("moduleId")
+	tokenId := c.PathParam("tokenId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body RotateModuleTokenBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	gracePeriod := ModuleTokenGracePeriod
+	if body.GracePeriod != nil {
+		gracePeriod = time.Duration(*body.GracePeriod) * time.Second
+	}
+
+	module, err := app.GetModuleByOrganizationIdAndId(organizationId, moduleId)
+	if err != nil || module == nil {
+		return apis.NewApiError(404, "no module found ...", nil)
+	}
+
+	moduleToken, err := app.GetModuleTokenByModuleIdAndTokenId(module.Id, tokenId)
+	if err != nil || moduleToken == nil {
+		return apis.NewApiError(404, "module token not found ...", nil)
+	}
+
+	if moduleToken.GetString("revoked_at") != "" || moduletoken.Expired(moduleToken.GetString("expires_at"), time.Now()) {
+		return apis.NewApiError(400, "module token is revoked or expired ...", nil)
+	}
+
+	token, created, err := app.RotateModuleToken(moduleToken, gracePeriod)
+	if err != nil {
+		return apis.NewApiError(500, "can't rotate module token ...", nil)
+	}
+
+	return c.JSON(200, map[string]any{
+		"token":  token,
+		"record": moduleTokenJSON(created),
+	})
+}
+
+func (app *application) deleteModuleToken(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	moduleId := c.PathParam("moduleId")
+	tokenId := c.PathParam("tokenId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	module, err := app.GetModuleByOrganizationIdAndId(organizationId, moduleId)
+	if err != nil || module == nil {
+		return apis.NewApiError(404, "no module found ...", nil)
+	}
+
+	moduleToken, err := app.GetModuleTokenByModuleIdAndTokenId(module.Id, tokenId)
+	if err != nil || moduleToken == nil {
+		return apis.NewApiError(404, "module token not found ...", nil)
+	}
+
+	if moduleToken.GetString("revoked_at") != "" {
+		return c.JSON(200, nil)
+	}
+
+	// the record stay, revoked, so the sessions keep a trace of their token
+	if err := app.RevokeModuleToken(moduleToken); err != nil {
+		return apis.NewApiError(500, "can't revoke module token ...", nil)
+	}
+
+	return c.JSON(200, nil)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"time"
+)
+
+func (app *application) deleteProcess(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	processId := c.PathParam("processId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can access this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && role != null",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	process, err := app.GetRunningProcessByOrganizationIdAndProcessId(organizationId, processId)
+
+	if err != nil || process == nil {
+		return apis.NewApiError(404, "no running process found ...", nil)
+	}
+
+	msgJson, err := json.Marshal(map[string]any{
+		"type":   "process",
+		"action": "cancel",
+		"payload": map[string]any{
+			"user": info.AuthRecord.Id,
+		},
+	})
+	if err != nil {
+		return apis.NewApiError(500, "can't cancel process ...", nil)
+	}
+
+	_, err = app.realtime.Request(app.realtime.GetChannelForProcess(process.Id), msgJson, time.Second*3)
+
+	// no event service is running this process but it is not stopped
+	if err != nil {
+		if err := app.CancelProcess(process, info.AuthRecord.Id); err != nil {
+			return apis.NewApiError(500, "can't cancel process ...", nil)
+		}
+	}
+
+	return c.JSON(200, nil)
+}
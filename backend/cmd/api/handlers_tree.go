@@ -355,6 +355,7 @@ func (app *application) duplicateTree(c echo.Context) error {
 			newSharedRecord.Set("organization", sharedRecord.GetString("organization"))
 			newSharedRecord.Set("name", strings.Replace(sharedRecord.GetString("name"), path, targetPath, 1))
 			newSharedRecord.Set("code", sharedRecord.GetString("code"))
+			newSharedRecord.Set("language", sharedRecord.GetString("language"))
 			newSharedRecord.Set("enable", false)
 			if err := txDao.SaveRecord(newSharedRecord); err != nil {
 				return err
@@ -366,7 +367,9 @@ func (app *application) duplicateTree(c echo.Context) error {
 			newTriggerRecord.Set("organization", triggerRecord.GetString("organization"))
 			newTriggerRecord.Set("name", strings.Replace(triggerRecord.GetString("name"), path, targetPath, 1))
 			newTriggerRecord.Set("code", triggerRecord.GetString("code"))
+			newTriggerRecord.Set("language", triggerRecord.GetString("language"))
 			newTriggerRecord.Set("channel", triggerRecord.GetString("channel"))
+			newTriggerRecord.Set("max_execution_time", triggerRecord.GetInt("max_execution_time"))
 			newTriggerRecord.Set("enable", false)
 			if err := txDao.SaveRecord(newTriggerRecord); err != nil {
 				return err
@@ -394,8 +397,11 @@ func (app *application) duplicateTree(c echo.Context) error {
 				newConditionRecord.Set("trigger", newTriggerRecord.Id)
 				newConditionRecord.Set("name", conditionRecord.GetString("name"))
 				newConditionRecord.Set("code", conditionRecord.GetString("code"))
+				newConditionRecord.Set("language", conditionRecord.GetString("language"))
 				newConditionRecord.Set("enable", conditionRecord.GetString("enable"))
 				newConditionRecord.Set("timeout", conditionRecord.GetString("timeout"))
+				newConditionRecord.Set("max_execution_time", conditionRecord.GetInt("max_execution_time"))
+				newConditionRecord.Set("matcher", conditionRecord.Get("matcher"))
 				newConditionRecord.Set("type", conditionRecord.GetString("type"))
 
 				if err := txDao.SaveRecord(newConditionRecord); err != nil {
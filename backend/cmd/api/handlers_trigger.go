@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/labstack/echo/v5"
+	"github.com/nats-io/nats.go"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"time"
+)
+
+// above the max execution time of a test in the event service
+const triggerTestTimeout = 30 * time.Second
+
+type TestTriggerBody struct {
+	Condition   string          `json:"condition"`
+	EventName   string          `json:"event_name"`
+	Payload     json.RawMessage `json:"payload"`
+	CustomEvent string          `json:"custom_event"`
+}
+
+func (app *application) postTestTrigger(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	triggerId := c.PathParam("triggerId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can access this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && role != null",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body TestTriggerBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	event := model.Event{
+		OrganizationId: organizationId,
+		Name:           body.EventName,
+		Payload:        body.Payload,
+		EmitterCode:    "test",
+		EmitterName:    info.AuthRecord.Username(),
+		EmittedAt:      time.Now().Format(time.RFC3339Nano),
+	}
+
+	// a custom event give both the name and the payload
+	if body.CustomEvent != "" {
+		customEvent, err := app.GetCustomEventByOrganizationIdAndCustomEventId(organizationId, body.CustomEvent)
+
+		if err != nil || customEvent == nil {
+			return apis.NewApiError(404, "custom event not found ...", nil)
+		}
+
+		event.Name = customEvent.GetString("name")
+		event.Payload = json.RawMessage(customEvent.GetString("payload"))
+	}
+
+	if len(event.Payload) == 0 {
+		event.Payload = json.RawMessage("null")
+	}
+
+	trigger, err := app.pb.Dao().FindFirstRecordByFilter(
+		"triggers",
+		"organization = {:organizationId} && id = {:triggerId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"triggerId":      triggerId,
+		},
+	)
+
+	if err != nil || trigger == nil {
+		return apis.NewApiError(404, "trigger not found ...", nil)
+	}
+
+	msgJson, err := json.Marshal(model.TriggerTestRequest{
+		OrganizationId: organizationId,
+		TriggerId:      triggerId,
+		ConditionId:    body.Condition,
+		Event:          event,
+	})
+	if err != nil {
+		return apis.NewApiError(500, "can't test trigger ...", nil)
+	}
+
+	msg, err := app.realtime.Request(app.realtime.GetChannelForTriggerTest(), msgJson, triggerTestTimeout)
+	if errors.Is(err, nats.ErrTimeout) {
+		return apis.NewApiError(504, "trigger test timed out ...", nil)
+	}
+	if err != nil {
+		return apis.NewApiError(503, "no event service available to test trigger ...", nil)
+	}
+
+	var result model.TriggerTestResponse
+	if err := json.Unmarshal(msg.Data, &result); err != nil {
+		return apis.NewApiError(500, "can't test trigger ...", nil)
+	}
+
+	if result.Error != "" {
+		return apis.NewApiError(400, result.Error, nil)
+	}
+
+	return c.JSON(200, result)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+)
+
+func (app *application) getTypeDefinitions(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can access this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && role != null",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	definitions, err := app.GenerateTypeDefinitions(organizationId)
+
+	if err != nil {
+		return apis.NewApiError(400, "error when trying to generate type definitions ...", nil)
+	}
+
+	return c.Blob(200, "application/typescript; charset=utf-8", []byte(definitions))
+}
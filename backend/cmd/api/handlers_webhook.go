@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"time"
+)
+
+type RotateWebhookSecretBody struct {
+	// seconds during which the previous secret is still accepted
+	GracePeriod *int `json:"grace_period"`
+}
+
+func (app *application) postRotateWebhookSecret(c echo.Context) error {
+	organizationId := c.PathParam("organizationId")
+	webhookId := c.PathParam("webhookId")
+	info := apis.RequestInfo(c)
+
+	// verify if user can write in this organization
+	record, err := app.pb.Dao().FindFirstRecordByFilter(
+		"user_organization",
+		"user.id = {:userId} && organization.id = {:organizationId} && (role = 'WRITE' || role = 'CREATOR')",
+		dbx.Params{
+			"userId":         info.AuthRecord.Id,
+			"organizationId": organizationId,
+		},
+	)
+
+	if err != nil || record == nil {
+		return apis.NewApiError(401, "you can't access to this organization", nil)
+	}
+
+	var body RotateWebhookSecretBody
+	if err := c.Bind(&body); err != nil {
+		return apis.NewApiError(400, "invalid body ...", nil)
+	}
+
+	gracePeriod := WebhookSecretGracePeriod
+	if body.GracePeriod != nil {
+		gracePeriod = time.Duration(*body.GracePeriod) * time.Second
+	}
+
+	webhook, err := app.GetWebhookByOrganizationIdAndWebhookId(organizationId, webhookId)
+	if err != nil || webhook == nil {
+		return apis.NewApiError(404, "webhook not found ...", nil)
+	}
+
+	secret, err := app.RotateWebhookSecret(webhook, gracePeriod)
+	if err != nil {
+		return apis.NewApiError(500, "can't rotate webhook secret ...", nil)
+	}
+
+	return c.JSON(200, map[string]any{
+		"secret": secret,
+	})
+}
@@ -33,6 +33,10 @@ func main() {
 		pb:       pocketbase.New(),
 	}
 
+	if err := app.realtime.EnsureEventStream(); err != nil {
+		log.Fatal(err)
+	}
+
 	migratecmd.MustRegister(app.pb, app.pb.RootCmd, migratecmd.Config{
 		TemplateLang: migratecmd.TemplateLangGo,
 		// enable auto creation of migration files when making collection changes in the Admin UI
@@ -46,10 +50,30 @@ func main() {
 	app.pb.OnModelAfterDelete("storages").Add(app.onModelStorage)
 	app.pb.OnRecordAfterCreateRequest("organizations").Add(app.onCreateOrganization)
 
+	for _, collection := range []string{"triggers", "trigger_conditions", "shareds"} {
+		app.pb.OnModelBeforeCreate(collection).Add(app.onBeforeSaveCode)
+		app.pb.OnModelBeforeUpdate(collection).Add(app.onBeforeSaveCode)
+		app.pb.OnModelAfterCreate(collection).Add(app.onModelInvalidateCache)
+		app.pb.OnModelAfterUpdate(collection).Add(app.onModelInvalidateCache)
+		app.pb.OnModelAfterDelete(collection).Add(app.onModelInvalidateCache)
+	}
+
 	app.pb.OnModelBeforeCreate("triggers").Add(app.onBeforeCreateTrigger)
 	app.pb.OnModelBeforeCreate("shareds").Add(app.onBeforeCreateShared)
+	app.pb.OnModelBeforeCreate("trigger_conditions").Add(app.onBeforeSaveTriggerCondition)
+	app.pb.OnModelBeforeUpdate("trigger_conditions").Add(app.onBeforeSaveTriggerCondition)
+	app.pb.OnModelBeforeCreate("schedules").Add(app.onBeforeSaveSchedule)
+	app.pb.OnModelBeforeUpdate("schedules").Add(app.onBeforeSaveSchedule)
+	app.pb.OnModelBeforeCreate("event_schemas").Add(app.onBeforeSaveEventSchema)
+	app.pb.OnModelBeforeUpdate("event_schemas").Add(app.onBeforeSaveEventSchema)
 
 	app.pb.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		stopScheduler := app.startScheduler()
+		app.pb.OnTerminate().Add(func(e *core.TerminateEvent) error {
+			stopScheduler()
+			return nil
+		})
+
 		g := e.Router.Group("/api")
 		g.GET("/organization/:organizationId/tree", app.getTree)
 		g.DELETE("/organization/:organizationId/tree", app.deleteTree)
@@ -58,9 +82,20 @@ func main() {
 		g.GET("/organization/:organizationId/tree/move", app.moveTree)
 		g.GET("/organization/:organizationId/tree/duplicate", app.duplicateTree)
 		g.GET("/organization/:organizationId/export", app.getExport)
+		g.GET("/organization/:organizationId/types", app.getTypeDefinitions)
 		g.POST("/organization/:organizationId/import", app.postImport)
 		g.GET("/organization/:organizationId/event/available", app.getAvailableEventNames)
+		g.GET("/organization/:organizationId/event/schemas", app.getEventSchemas)
+		g.POST("/organization/:organizationId/event/:eventId/replay", app.postReplayEvent)
+		g.POST("/organization/:organizationId/trigger/:triggerId/test", app.postTestTrigger)
+		g.POST("/organization/:organizationId/custom-event/:customEventId/emit", app.postEmitCustomEvent)
+		g.GET("/organization/:organizationId/modules/:moduleId/manifest", app.getModuleManifest)
 		g.DELETE("/organization/:organizationId/modules/:moduleId/eject", app.deleteEjectModule)
+		g.POST("/organization/:organizationId/modules/:moduleId/tokens", app.postModuleToken)
+		g.POST("/organization/:organizationId/modules/:moduleId/tokens/:tokenId/rotate", app.postRotateModuleToken)
+		g.DELETE("/organization/:organizationId/modules/:moduleId/tokens/:tokenId", app.deleteModuleToken)
+		g.DELETE("/organization/:organizationId/process/:processId", app.deleteProcess)
+		g.POST("/organization/:organizationId/webhook/:webhookId/rotate", app.postRotateWebhookSecret)
 		return nil
 	})
 
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/models"
+	"time"
 )
 
 func (app *application) GetModulesWithSessionByOrganizationIdAndStorageKey(organizationId string, storageKey string) ([]*models.Record, error) {
@@ -35,7 +37,34 @@ func (app *application) GetModuleByOrganizationIdAndModuleId(organizationId stri
 	return resp, nil
 }
 
-func (app *application) ResetModuleSession(moduleId string) error {
+// GetModuleByOrganizationIdAndId find a module connected or not
+func (app *application) GetModuleByOrganizationIdAndId(organizationId string, moduleId string) (*models.Record, error) {
+	return app.pb.Dao().FindFirstRecordByFilter(
+		"modules",
+		"organization = {:organizationId} && id = {:moduleId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"moduleId":       moduleId,
+		},
+	)
+}
+
+func (app *application) GetModuleInstances(moduleId string) ([]*models.Record, error) {
+	return app.pb.Dao().FindRecordsByFilter(
+		"module_sessions",
+		"module = {:moduleId}",
+		"created",
+		0,
+		0,
+		dbx.Params{
+			"moduleId": moduleId,
+		},
+	)
+}
+
+// ResetModuleSession remove an instance that is gone, the module keep the
+// session of another instance or none
+func (app *application) ResetModuleSession(moduleId string, session string) error {
 	record, err := app.pb.Dao().FindFirstRecordByFilter(
 		"modules",
 		"id = {:moduleId}",
@@ -48,10 +77,54 @@ func (app *application) ResetModuleSession(moduleId string) error {
 		return err
 	}
 
-	record.Set("session", "")
+	instances, err := app.GetModuleInstances(moduleId)
+	if err != nil {
+		return err
+	}
+
+	next := ""
+	for _, instance := range instances {
+		if instance.GetString("session") == session {
+			if err := app.pb.Dao().DeleteRecord(instance); err != nil {
+				return err
+			}
+			continue
+		}
+		if next == "" {
+			next = instance.GetString("session")
+		}
+	}
+
+	record.Set("session", next)
 
 	if err := app.pb.Dao().SaveRecord(record); err != nil {
 		return err
 	}
 	return nil
 }
+
+// EjectModuleInstances ask the replicas holding the instances to close them,
+// the reason is given to the module.disconnected event
+func (app *application) EjectModuleInstances(moduleId string, instances []*models.Record, reason string) error {
+	msgJson, err := json.Marshal(map[string]any{
+		"type":   "module",
+		"action": "eject",
+		"reason": reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		_, err := app.realtime.Request(app.realtime.GetChannelForModule(instance.GetString("session")), msgJson, time.Second*3)
+
+		// module is not connect but session is set
+		if err != nil {
+			if err := app.ResetModuleSession(moduleId, instance.GetString("session")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
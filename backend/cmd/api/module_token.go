@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/evntboard/app/backend/internal/moduletoken"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"time"
+)
+
+const ModuleTokenGracePeriod = 24 * time.Hour
+
+func (app *application) GetModuleTokenByModuleIdAndTokenId(moduleId, tokenId string) (*models.Record, error) {
+	return app.pb.Dao().FindFirstRecordByFilter(
+		"module_tokens",
+		"module = {:moduleId} && id = {:tokenId}",
+		dbx.Params{
+			"moduleId": moduleId,
+			"tokenId":  tokenId,
+		},
+	)
+}
+
+func (app *application) GetModuleInstancesByTokenId(tokenId string) ([]*models.Record, error) {
+	return app.pb.Dao().FindRecordsByFilter(
+		"module_sessions",
+		"token = {:tokenId}",
+		"created",
+		0,
+		0,
+		dbx.Params{
+			"tokenId": tokenId,
+		},
+	)
+}
+
+// CreateModuleToken store a new token of the module, the plain token is only
+// returned here
+func (app *application) CreateModuleToken(moduleId, label string, methods, events []string, expiresAt string) (string, *models.Record, error) {
+	collection, err := app.pb.Dao().FindCollectionByNameOrId("module_tokens")
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, prefix, hash := moduletoken.Generate()
+
+	record := models.NewRecord(collection)
+	record.Set("module", moduleId)
+	record.Set("label", label)
+	record.Set("hash", hash)
+	record.Set("prefix", prefix)
+	record.Set("methods", methods)
+	record.Set("events", events)
+	record.Set("expires_at", expiresAt)
+
+	if err := app.pb.Dao().SaveRecord(record); err != nil {
+		return "", nil, err
+	}
+
+	return token, record, nil
+}
+
+// RotateModuleToken create a token with the same scopes, the current one is
+// still accepted for gracePeriod so the module can be updated without losing
+// its session. Without grace period it is revoked now. The new token keep the
+// expiry of the rotated one.
+func (app *application) RotateModuleToken(record *models.Record, gracePeriod time.Duration) (string, *models.Record, error) {
+	token, created, err := app.CreateModuleToken(
+		record.GetString("module"),
+		record.GetString("label"),
+		record.GetStringSlice("methods"),
+		moduleTokenEvents(record),
+		record.GetString("expires_at"),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if gracePeriod <= 0 {
+		return token, created, app.RevokeModuleToken(record)
+	}
+
+	expiresAt := time.Now().Add(gracePeriod).UTC()
+
+	// a token expiring sooner keep its date
+	if current := record.GetDateTime("expires_at"); current.IsZero() || current.Time().After(expiresAt) {
+		record.Set("expires_at", expiresAt)
+	}
+
+	if err := app.pb.Dao().SaveRecord(record); err != nil {
+		return "", nil, err
+	}
+
+	return token, created, nil
+}
+
+// RevokeModuleToken refuse the token from now, the sessions that registered
+// with it are ejected
+func (app *application) RevokeModuleToken(record *models.Record) error {
+	record.Set("revoked_at", time.Now().UTC())
+
+	if err := app.pb.Dao().SaveRecord(record); err != nil {
+		return err
+	}
+
+	instances, err := app.GetModuleInstancesByTokenId(record.Id)
+	if err != nil {
+		return err
+	}
+
+	return app.EjectModuleInstances(record.GetString("module"), instances, "revoked")
+}
+
+func moduleTokenEvents(record *models.Record) []string {
+	var events []string
+	_ = json.Unmarshal([]byte(record.GetString("events")), &events)
+	return events
+}
+
+// moduleTokenJSON leave the hash out
+func moduleTokenJSON(record *models.Record) map[string]any {
+	return map[string]any{
+		"id":         record.Id,
+		"module":     record.GetString("module"),
+		"label":      record.GetString("label"),
+		"prefix":     record.GetString("prefix"),
+		"methods":    record.GetStringSlice("methods"),
+		"events":     moduleTokenEvents(record),
+		"expires_at": record.GetString("expires_at"),
+		"revoked_at": record.GetString("revoked_at"),
+		"created":    record.Created.String(),
+	}
+}
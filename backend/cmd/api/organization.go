@@ -1,7 +1,6 @@
 package main
 
 import (
-	"github.com/google/uuid"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/models"
@@ -48,25 +47,31 @@ func (app *application) onCreateOrganization(e *core.RecordCreateEvent) error {
 	recordModuleBoard.Set("organization", e.Record.Id)
 	recordModuleBoard.Set("code", "board")
 	recordModuleBoard.Set("name", "board")
-	recordModuleBoard.Set("token", uuid.NewString())
 	recordModuleBoard.Set("sub", "board;tmp:board")
 
 	if err := app.pb.Dao().SaveRecord(recordModuleBoard); err != nil {
 		return err
 	}
 
+	if _, _, err := app.CreateModuleToken(recordModuleBoard.Id, "default", nil, nil, ""); err != nil {
+		return err
+	}
+
 	// create module media
 	recordModuleMedia := models.NewRecord(collectionModule)
 	recordModuleMedia.Set("organization", e.Record.Id)
 	recordModuleMedia.Set("code", "media")
 	recordModuleMedia.Set("name", "media")
-	recordModuleMedia.Set("token", uuid.NewString())
 	recordModuleMedia.Set("sub", "")
 
 	if err := app.pb.Dao().SaveRecord(recordModuleMedia); err != nil {
 		return err
 	}
 
+	if _, _, err := app.CreateModuleToken(recordModuleMedia.Id, "default", nil, nil, ""); err != nil {
+		return err
+	}
+
 	// create README shared for board
 	recordSharedReadme := models.NewRecord(collectionShared)
 	recordSharedReadme.Set("organization", e.Record.Id)
@@ -82,7 +87,7 @@ func (app *application) onCreateOrganization(e *core.RecordCreateEvent) error {
 	recordSharedUtils := models.NewRecord(collectionShared)
 	recordSharedUtils.Set("organization", e.Record.Id)
 	recordSharedUtils.Set("name", "/example/board/utils")
-	recordSharedUtils.Set("code", "const randomNumber = (min, max) => Math.floor(Math.random() * (max - min + 1) + min);\nconst randomByte = () => randomNumber(0, 255)\nconst randomPercent = () => (randomNumber(50, 100) * 0.01).toFixed(2)\nconst randomCssRgba = () => `rgba(${[randomByte(), randomByte(), randomByte(), randomPercent()].join(',')})`")
+	recordSharedUtils.Set("code", "export const randomNumber = (min, max) => Math.floor(Math.random() * (max - min + 1) + min);\nconst randomByte = () => randomNumber(0, 255)\nconst randomPercent = () => (randomNumber(50, 100) * 0.01).toFixed(2)\nexport const randomCssRgba = () => `rgba(${[randomByte(), randomByte(), randomByte(), randomPercent()].join(',')})`")
 	recordSharedUtils.Set("enable", true)
 
 	if err := app.pb.Dao().SaveRecord(recordSharedUtils); err != nil {
@@ -93,7 +98,7 @@ func (app *application) onCreateOrganization(e *core.RecordCreateEvent) error {
 	recordTriggerBtn1 := models.NewRecord(collectionTrigger)
 	recordTriggerBtn1.Set("organization", e.Record.Id)
 	recordTriggerBtn1.Set("name", "/example/board/btn-1")
-	recordTriggerBtn1.Set("code", "// module.notify doesn't block the trigger execution\nconst result = module.notify(\n    \"board\",\n    \"updateText\",\n    {\n        \"text\": `${randomNumber(0,100)}`,\n        \"slug\": \"btn-1\"\n    }\n)\n\n// result is always null, module.notify return nothing\nlog('result', result)\n\n// wait for 100 ms\n\nsleep(100)\n\n// module.notify doesn't block the trigger execution\nmodule.notify(\n    \"board\",\n    \"updateColor\",\n    {\n        \"color\": `${randomCssRgba()}`,\n        \"slug\": \"txt-1\"\n    }\n)\n")
+	recordTriggerBtn1.Set("code", "import { randomNumber, randomCssRgba } from './utils'\n\n// module.notify doesn't block the trigger execution\nconst result = module.notify(\n    \"board\",\n    \"updateText\",\n    {\n        \"text\": `${randomNumber(0,100)}`,\n        \"slug\": \"btn-1\"\n    }\n)\n\n// result is always null, module.notify return nothing\nlog('result', result)\n\n// run after 100 ms without blocking the trigger\nsetTimeout(() => {\n    module.notify(\n        \"board\",\n        \"updateColor\",\n        {\n            \"color\": `${randomCssRgba()}`,\n            \"slug\": \"txt-1\"\n        }\n    )\n}, 100)\n")
 	recordTriggerBtn1.Set("enable", true)
 	recordTriggerBtn1.Set("channel", "")
 
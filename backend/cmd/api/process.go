@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"time"
+)
+
+func (app *application) GetRunningProcessByOrganizationIdAndProcessId(organizationId string, processId string) (*models.Record, error) {
+	resp, err := app.pb.Dao().FindFirstRecordByFilter(
+		"event_processes",
+		"end_at = \"\" && trigger.organization = {:organizationId} && id = {:processId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"processId":      processId,
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (app *application) CancelProcess(record *models.Record, userId string) error {
+	record.Set("end_at", time.Now().Format(time.RFC3339Nano))
+	record.Set("cancelled", true)
+	record.Set("cancelled_by", userId)
+	record.Set("error", "process cancelled")
+
+	if err := app.pb.Dao().SaveRecord(record); err != nil {
+		return err
+	}
+	return nil
+}
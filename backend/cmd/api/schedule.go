@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"log/slog"
+	"time"
+)
+
+const (
+	SchedulerEmitterCode = "scheduler"
+	schedulerTick        = time.Second
+)
+
+func (app *application) startScheduler() func() {
+	ticker := time.NewTicker(schedulerTick)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				app.runDueSchedules(now)
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// runDueSchedules only load the schedules whose next run is passed, a
+// schedule saved before next_run_at existed has none yet and is loaded once
+func (app *application) runDueSchedules(now time.Time) {
+	schedules, err := app.pb.Dao().FindRecordsByFilter(
+		"schedules",
+		"enable = true && (next_run_at = '' || next_run_at <= {:now})",
+		"next_run_at",
+		0,
+		0,
+		dbx.Params{"now": now.UTC().Format(types.DefaultDateLayout)},
+	)
+	if err != nil {
+		app.pb.Logger().Error("scheduler : unable to list schedules", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, schedule := range schedules {
+		// mark the run first, a failing event must not be emitted again every tick
+		claimed, err := app.claimSchedule(schedule, now)
+		if err != nil {
+			app.pb.Logger().Error(
+				"scheduler : unable to update schedule",
+				slog.String("id", schedule.Id),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		if !claimed {
+			continue
+		}
+
+		_, err = app.CreateEvent(
+			schedule.GetString("organization"),
+			schedule.GetString("event_name"),
+			json.RawMessage(schedule.GetString("payload")),
+			SchedulerEmitterCode,
+			schedule.GetString("name"),
+			"",
+		)
+		if err != nil {
+			app.pb.Logger().Error(
+				"scheduler : unable to emit event",
+				slog.String("id", schedule.Id),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// claimSchedule move last_run_at and next_run_at forward. The update only
+// apply if both are still what was read, with several api instances a single
+// one claim the run
+func (app *application) claimSchedule(schedule *models.Record, now time.Time) (bool, error) {
+	due, err := isScheduleDue(schedule, now)
+	if err != nil {
+		return false, err
+	}
+
+	lastRun := schedule.GetString("last_run_at")
+	if due {
+		schedule.Set("last_run_at", now.UTC())
+	}
+
+	nextRun, err := nextScheduleRun(schedule, now)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := app.pb.Dao().DB().NewQuery(`
+		UPDATE schedules SET last_run_at = {:newLastRun}, next_run_at = {:newNextRun}
+		WHERE id = {:id} AND last_run_at = {:lastRun} AND next_run_at = {:nextRun}
+	`).Bind(dbx.Params{
+		"id":         schedule.Id,
+		"lastRun":    lastRun,
+		"nextRun":    schedule.GetString("next_run_at"),
+		"newLastRun": schedule.GetString("last_run_at"),
+		"newNextRun": nextRun.String(),
+	}).Execute()
+	if err != nil {
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return due && claimed == 1, nil
+}
+
+// nextScheduleRun is one interval after the last run, or the next minute
+// matching the cron expression
+func nextScheduleRun(schedule *models.Record, now time.Time) (types.DateTime, error) {
+	if interval := schedule.GetInt("interval"); interval > 0 {
+		lastRun := schedule.GetDateTime("last_run_at").Time()
+		if lastRun.IsZero() {
+			lastRun = schedule.GetDateTime("created").Time()
+		}
+		return types.ParseDateTime(lastRun.Add(time.Duration(interval) * time.Second))
+	}
+
+	s, err := cron.NewSchedule(schedule.GetString("cron"))
+	if err != nil {
+		return types.DateTime{}, err
+	}
+
+	location, err := time.LoadLocation(schedule.GetString("timezone"))
+	if err != nil {
+		return types.DateTime{}, err
+	}
+
+	next, ok := nextCronMinute(s, now.In(location))
+	if !ok {
+		return types.DateTime{}, errors.New("the cron expression never match")
+	}
+
+	return types.ParseDateTime(next)
+}
+
+// nextCronMinute is the first minute after now matching the cron schedule,
+// whole months, days and hours are skipped when they can't match
+func nextCronMinute(s *cron.Schedule, now time.Time) (time.Time, bool) {
+	t := now.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	location := t.Location()
+
+	for t.Before(limit) {
+		if _, ok := s.Months[int(t.Month())]; !ok {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		_, dayOk := s.Days[t.Day()]
+		_, weekdayOk := s.DaysOfWeek[int(t.Weekday())]
+		if !dayOk || !weekdayOk {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if _, ok := s.Hours[t.Hour()]; !ok {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+
+		if _, ok := s.Minutes[t.Minute()]; !ok {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+func isScheduleDue(schedule *models.Record, now time.Time) (bool, error) {
+	lastRun := schedule.GetDateTime("last_run_at").Time()
+
+	if interval := schedule.GetInt("interval"); interval > 0 {
+		// the first run happen one interval after the creation
+		if lastRun.IsZero() {
+			lastRun = schedule.GetDateTime("created").Time()
+		}
+		return now.Sub(lastRun) >= time.Duration(interval)*time.Second, nil
+	}
+
+	s, err := cron.NewSchedule(schedule.GetString("cron"))
+	if err != nil {
+		return false, err
+	}
+
+	location, err := time.LoadLocation(schedule.GetString("timezone"))
+	if err != nil {
+		return false, err
+	}
+
+	// cron has a minute resolution, run once per matching minute
+	minute := now.Truncate(time.Minute)
+	if !lastRun.Before(minute) {
+		return false, nil
+	}
+
+	return s.IsDue(cron.NewMoment(minute.In(location))), nil
+}
+
+func (app *application) onBeforeSaveSchedule(e *core.ModelEvent) error {
+	record, _ := e.Model.(*models.Record)
+
+	cronExpr := record.GetString("cron")
+	interval := record.GetInt("interval")
+
+	if (cronExpr == "") == (interval <= 0) {
+		return errors.New("a schedule needs either a cron expression or an interval")
+	}
+
+	if cronExpr != "" {
+		if _, err := cron.NewSchedule(cronExpr); err != nil {
+			return err
+		}
+	}
+
+	if _, err := time.LoadLocation(record.GetString("timezone")); err != nil {
+		return err
+	}
+
+	// a changed cron, interval or timezone take effect on the next tick
+	nextRun, err := nextScheduleRun(record, time.Now())
+	if err != nil {
+		return err
+	}
+	record.Set("next_run_at", nextRun)
+
+	return nil
+}
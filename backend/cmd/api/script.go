@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/evntboard/app/backend/internal/script"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// onBeforeSaveCode transpile the typescript of triggers, conditions and
+// shareds, the event service only run the stored javascript
+func (app *application) onBeforeSaveCode(e *core.ModelEvent) error {
+	record, _ := e.Model.(*models.Record)
+
+	if record.GetString("language") != script.LanguageTypescript {
+		record.Set("compiled_code", "")
+		return nil
+	}
+
+	compiled, err := script.Transpile(record.GetString("name"), record.GetString("code"))
+	if err != nil {
+		return validation.Errors{
+			"code": validation.NewError("validation_invalid_typescript", err.Error()),
+		}
+	}
+
+	record.Set("compiled_code", compiled)
+
+	return nil
+}
@@ -10,9 +10,10 @@ import (
 )
 
 type ExportShared struct {
-	Entity string `json:"entity"`
-	Code   string `json:"code"`
-	Name   string `json:"name"`
+	Entity   string `json:"entity"`
+	Code     string `json:"code"`
+	Language string `json:"language,omitempty"`
+	Name     string `json:"name"`
 }
 
 func NewExportSharedFromAny(entity map[string]interface{}) ExportShared {
@@ -27,6 +28,10 @@ func NewExportSharedFromAny(entity map[string]interface{}) ExportShared {
 			if v, ok := value.(string); ok {
 				shared.Code = v
 			}
+		case "language":
+			if v, ok := value.(string); ok {
+				shared.Language = v
+			}
 		case "name":
 			if v, ok := value.(string); ok {
 				shared.Name = v
@@ -47,6 +52,7 @@ func (app *application) CreateSharedFromExport(organizationId, path string, expo
 	record.Set("organization", organizationId)
 	record.Set("name", utils.RemoveLastChar(path)+export.Name)
 	record.Set("code", export.Code)
+	record.Set("language", export.Language)
 
 	if err := app.pb.Dao().SaveRecord(record); err != nil {
 		return err
@@ -26,9 +26,17 @@ func (app *application) onModelStorage(e *core.ModelEvent) error {
 	}
 
 	for _, moduleRecord := range moduleRecords {
-		if err := app.realtime.Publish(app.realtime.GetChannelForModule(moduleRecord.GetString("session")), msgJson); err != nil {
+		instances, err := app.GetModuleInstances(moduleRecord.Id)
+		if err != nil {
 			return err
 		}
+
+		// every instance keep its own copy of the storage
+		for _, instance := range instances {
+			if err := app.realtime.Publish(app.realtime.GetChannelForModule(instance.GetString("session")), msgJson); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -2,11 +2,16 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/matcher"
+	"github.com/evntboard/app/backend/internal/pattern"
 	"github.com/evntboard/app/backend/utils"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type TriggerCondition struct {
@@ -23,18 +28,23 @@ type TriggerCondition struct {
 }
 
 type ExportTrigger struct {
-	Entity     string                   `json:"entity"`
-	Code       string                   `json:"code"`
-	Name       string                   `json:"name"`
-	Channel    string                   `json:"channel"`
-	Conditions []ExportTriggerCondition `json:"conditions"`
+	Entity           string                   `json:"entity"`
+	Code             string                   `json:"code"`
+	Language         string                   `json:"language,omitempty"`
+	Name             string                   `json:"name"`
+	Channel          string                   `json:"channel"`
+	MaxExecutionTime int32                    `json:"max_execution_time"`
+	Conditions       []ExportTriggerCondition `json:"conditions"`
 }
 
 type ExportTriggerCondition struct {
-	Code    string `json:"code"`
-	Name    string `json:"name"`
-	Timeout int32  `json:"timeout"`
-	Type    string `json:"type"`
+	Code             string        `json:"code"`
+	Language         string        `json:"language,omitempty"`
+	Name             string        `json:"name"`
+	Timeout          int32         `json:"timeout"`
+	MaxExecutionTime int32         `json:"max_execution_time"`
+	Type             string        `json:"type"`
+	Matcher          types.JsonRaw `json:"matcher,omitempty"`
 }
 
 func (app *application) GetAvailableConditionNames(organizationId string) ([]*EventName, error) {
@@ -72,7 +82,9 @@ func (app *application) CreateTriggerFromExport(organizationId, path string, exp
 	recordT.Set("organization", organizationId)
 	recordT.Set("name", utils.RemoveLastChar(path)+export.Name)
 	recordT.Set("code", export.Code)
+	recordT.Set("language", export.Language)
 	recordT.Set("channel", export.Channel)
+	recordT.Set("max_execution_time", export.MaxExecutionTime)
 
 	if err := app.pb.Dao().SaveRecord(recordT); err != nil {
 		return err
@@ -83,8 +95,11 @@ func (app *application) CreateTriggerFromExport(organizationId, path string, exp
 		recordC.Set("trigger", recordT.Id)
 		recordC.Set("name", condition.Name)
 		recordC.Set("code", condition.Code)
+		recordC.Set("language", condition.Language)
 		recordC.Set("type", condition.Type)
 		recordC.Set("timeout", condition.Timeout)
+		recordC.Set("max_execution_time", condition.MaxExecutionTime)
+		recordC.Set("matcher", condition.Matcher)
 
 		if err := app.pb.Dao().SaveRecord(recordC); err != nil {
 			return err
@@ -106,6 +121,10 @@ func NewExportTriggerFromAny(entity map[string]interface{}) ExportTrigger {
 			if v, ok := value.(string); ok {
 				trigger.Code = v
 			}
+		case "language":
+			if v, ok := value.(string); ok {
+				trigger.Language = v
+			}
 		case "name":
 			if v, ok := value.(string); ok {
 				trigger.Name = v
@@ -114,6 +133,10 @@ func NewExportTriggerFromAny(entity map[string]interface{}) ExportTrigger {
 			if v, ok := value.(string); ok {
 				trigger.Channel = v
 			}
+		case "max_execution_time":
+			if v, ok := value.(float64); ok {
+				trigger.MaxExecutionTime = int32(v)
+			}
 		case "conditions":
 			if conditions, ok := value.([]interface{}); ok {
 				for _, condition := range conditions {
@@ -125,6 +148,10 @@ func NewExportTriggerFromAny(entity map[string]interface{}) ExportTrigger {
 								if v, ok := conditionValue.(string); ok {
 									conditionStruct.Code = v
 								}
+							case "language":
+								if v, ok := conditionValue.(string); ok {
+									conditionStruct.Language = v
+								}
 							case "name":
 								if v, ok := conditionValue.(string); ok {
 									conditionStruct.Name = v
@@ -137,6 +164,14 @@ func NewExportTriggerFromAny(entity map[string]interface{}) ExportTrigger {
 								if v, ok := conditionValue.(string); ok {
 									conditionStruct.Type = v
 								}
+							case "max_execution_time":
+								if v, ok := conditionValue.(float64); ok {
+									conditionStruct.MaxExecutionTime = int32(v)
+								}
+							case "matcher":
+								if v, err := json.Marshal(conditionValue); err == nil && conditionValue != nil {
+									conditionStruct.Matcher = v
+								}
 							}
 						}
 						trigger.Conditions = append(trigger.Conditions, conditionStruct)
@@ -148,6 +183,20 @@ func NewExportTriggerFromAny(entity map[string]interface{}) ExportTrigger {
 	return trigger
 }
 
+func (app *application) onBeforeSaveTriggerCondition(e *core.ModelEvent) error {
+	record, _ := e.Model.(*models.Record)
+
+	if _, err := pattern.Parse(record.GetString("name")); err != nil {
+		return err
+	}
+
+	if _, err := matcher.Parse([]byte(record.GetString("matcher"))); err != nil {
+		return fmt.Errorf("invalid matcher : %s", err.Error())
+	}
+
+	return nil
+}
+
 func (app *application) onBeforeCreateTrigger(e *core.ModelEvent) error {
 	record, _ := e.Model.(*models.Record)
 
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/pattern"
+	"github.com/evntboard/app/backend/internal/typings"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type EventPayload struct {
+	Name    string        `db:"name"`
+	Payload types.JsonRaw `db:"payload"`
+}
+
+type ModuleMethod struct {
+	Code   string `db:"code"`
+	Method string `db:"method"`
+}
+
+// systemEvents are emitted by the module service when a module instance
+// connect or is gone
+var systemEvents = map[string]typings.Event{
+	"module.connected": {
+		Name:        "module.connected",
+		Description: "a module instance registered",
+		Payload:     "{\n\tid: string;\n\tcode: string;\n\tname: string;\n\tsession: string;\n}",
+	},
+	"module.disconnected": {
+		Name:        "module.disconnected",
+		Description: "a module instance is gone, after its grace period when it could resume",
+		Payload:     "{\n\tid: string;\n\tcode: string;\n\tname: string;\n\tsession: string;\n\treason: \"ejected\" | \"disconnected\" | \"expired\" | \"replaced\" | \"reclaimed\" | \"revoked\";\n}",
+	},
+}
+
+// GetLatestEventPayloads return the payload of the last event of each name
+func (app *application) GetLatestEventPayloads(organizationId string) ([]*EventPayload, error) {
+	var payloads []*EventPayload
+
+	// sqlite take the bare columns from the row holding the MAX, the
+	// (organization, name, created) index serve the whole query
+	err := app.pb.Dao().DB().
+		NewQuery(
+			"SELECT name, payload, MAX(created) AS created FROM events " +
+				"WHERE organization = {:organizationId} GROUP BY name",
+		).
+		Bind(dbx.Params{"organizationId": organizationId}).
+		All(&payloads)
+
+	return payloads, err
+}
+
+// GetCalledModuleMethods return the methods the triggers already called on
+// each module code
+func (app *application) GetCalledModuleMethods(organizationId string) ([]*ModuleMethod, error) {
+	var methods []*ModuleMethod
+
+	err := app.pb.Dao().DB().
+		Select(
+			"modules.code as code",
+			"event_process_requests.method as method",
+		).
+		Distinct(true).
+		From("event_process_requests").
+		InnerJoin("modules", dbx.NewExp("event_process_requests.module = modules.id")).
+		Where(dbx.HashExp{
+			"modules.organization": organizationId,
+		}).
+		All(&methods)
+
+	return methods, err
+}
+
+// GenerateTypeDefinitions build the .d.ts of the trigger globals : the
+// payload of an event come from its schema, else from a module manifest or the
+// system events, else from the last recorded one
+func (app *application) GenerateTypeDefinitions(organizationId string) (string, error) {
+	var definitions typings.Definitions
+
+	events := make(map[string]*typings.Event)
+
+	schemas, err := app.GetEventSchemas(organizationId)
+	if err != nil {
+		return "", err
+	}
+
+	for _, schema := range schemas {
+		events[schema.Name] = &typings.Event{
+			Name:        schema.Name,
+			Description: schema.Description,
+			Payload:     typings.FromJsonSchema(schema.Schema),
+		}
+	}
+
+	moduleRecords, err := app.pb.Dao().FindRecordsByFilter(
+		"modules",
+		"organization = {:organizationId}",
+		"name",
+		0,
+		0,
+		dbx.Params{"organizationId": organizationId},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	manifests := make(map[string]*model.ModuleManifest)
+	for _, record := range moduleRecords {
+		var manifest *model.ModuleManifest
+		if err := record.UnmarshalJSONField("manifest", &manifest); err != nil || manifest == nil {
+			continue
+		}
+		manifests[record.Id] = manifest
+
+		// the organization schema win over what the module declare
+		for _, declared := range manifest.Events {
+			if _, ok := events[declared.Name]; !ok {
+				events[declared.Name] = &typings.Event{
+					Name:        declared.Name,
+					Description: declared.Description,
+					Payload:     typings.FromJsonSchema(declared.Payload),
+				}
+			}
+		}
+	}
+
+	for name, event := range systemEvents {
+		if _, ok := events[name]; !ok {
+			event := event
+			events[name] = &event
+		}
+	}
+
+	payloads, err := app.GetLatestEventPayloads(organizationId)
+	if err != nil {
+		return "", err
+	}
+
+	for _, payload := range payloads {
+		if _, ok := events[payload.Name]; !ok {
+			events[payload.Name] = &typings.Event{
+				Name:    payload.Name,
+				Payload: typings.FromValue(payload.Payload),
+			}
+		}
+	}
+
+	conditionNames, err := app.GetAvailableConditionNames(organizationId)
+	if err != nil {
+		return "", err
+	}
+
+	for _, condition := range conditionNames {
+		if _, ok := events[condition.Name]; !ok && pattern.IsExactName(condition.Name) {
+			events[condition.Name] = &typings.Event{
+				Name:    condition.Name,
+				Payload: "any",
+			}
+		}
+	}
+
+	for _, event := range events {
+		definitions.Events = append(definitions.Events, *event)
+	}
+
+	moduleMethods, err := app.GetCalledModuleMethods(organizationId)
+	if err != nil {
+		return "", err
+	}
+
+	methodsByCode := make(map[string][]typings.Method)
+	for _, m := range moduleMethods {
+		methodsByCode[m.Code] = append(methodsByCode[m.Code], typings.Method{Name: m.Method})
+	}
+
+	for _, record := range moduleRecords {
+		module := typings.Module{
+			Name:    record.GetString("name"),
+			Code:    record.GetString("code"),
+			Methods: methodsByCode[record.GetString("code")],
+			Open:    true,
+		}
+
+		// a manifest is the whole list of methods, the calls are only a guess
+		if manifest, ok := manifests[record.Id]; ok {
+			module.Methods = nil
+			module.Open = false
+			for _, method := range manifest.Methods {
+				module.Methods = append(module.Methods, typings.Method{
+					Name:        method.Name,
+					Description: method.Description,
+					Params:      typings.FromJsonSchema(method.Params),
+					Result:      typings.FromJsonSchema(method.Result),
+				})
+			}
+		}
+
+		definitions.Modules = append(definitions.Modules, module)
+	}
+
+	return definitions.Render(), nil
+}
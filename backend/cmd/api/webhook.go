@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"time"
+)
+
+const (
+	webhookSecretLength      = 40
+	WebhookSecretGracePeriod = 24 * time.Hour
+)
+
+func (app *application) GetWebhookByOrganizationIdAndWebhookId(organizationId, webhookId string) (*models.Record, error) {
+	return app.pb.Dao().FindFirstRecordByFilter(
+		"webhooks",
+		"organization = {:organizationId} && id = {:webhookId}",
+		dbx.Params{
+			"organizationId": organizationId,
+			"webhookId":      webhookId,
+		},
+	)
+}
+
+// RotateWebhookSecret sets a new secret, the current one is still accepted
+// for gracePeriod so the sender can be updated without losing events.
+func (app *application) RotateWebhookSecret(record *models.Record, gracePeriod time.Duration) (string, error) {
+	secret := security.RandomString(webhookSecretLength)
+
+	if previous := record.GetString("secret"); previous != "" && gracePeriod > 0 {
+		record.Set("previous_secret", previous)
+		record.Set("previous_secret_expires_at", time.Now().Add(gracePeriod).UTC())
+	} else {
+		record.Set("previous_secret", "")
+		record.Set("previous_secret_expires_at", "")
+	}
+
+	record.Set("secret", secret)
+
+	if err := app.pb.Dao().SaveRecord(record); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/evntboard/app/backend/internal/model"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+const benchmarkOrganizationId = "benchorg0000001"
+
+const benchmarkSharedCode = `
+export function formatUser(user) {
+	return user.name.trim().toLowerCase() + "#" + user.id;
+}
+
+export function sum(values) {
+	return values.reduce(function (acc, value) { return acc + value; }, 0);
+}
+
+export var thresholds = { low: 10, medium: 100, high: 1000 };
+`
+
+const benchmarkConditionCode = `
+import { thresholds } from './shared-2';
+event.payload.amount >= thresholds.low && event.payload.user.name !== ""
+`
+
+const benchmarkTriggerCode = `
+import { formatUser } from '/folder/shared-0';
+import { sum } from './shared-1';
+var label = formatUser(event.payload.user);
+var total = sum(event.payload.items.map(function (item) { return item.price * item.quantity; }));
+label + " : " + total;
+`
+
+func newBenchmarkApplication() (*application, []*model.Shared, *model.TriggerCondition) {
+	app := &application{
+		config: config{
+			maxExecutionTime: time.Second,
+			cacheTTL:         time.Hour,
+		},
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		processes: make(map[string]*VMContext),
+		cache:     make(map[string]cacheEntry),
+		programs:  newProgramCache(),
+	}
+
+	shareds := make([]*model.Shared, 0, 5)
+	for i := 0; i < 5; i++ {
+		shareds = append(shareds, &model.Shared{
+			OrganizationId: benchmarkOrganizationId,
+			Id:             fmt.Sprintf("shared%09d", i),
+			Name:           fmt.Sprintf("/folder/shared-%d", i),
+			Code:           benchmarkSharedCode,
+			Enable:         true,
+			Updated:        "2024-04-20 10:00:00.000Z",
+		})
+	}
+
+	condition := &model.TriggerCondition{
+		Id:      "condition000001",
+		Name:    "donation",
+		Code:    benchmarkConditionCode,
+		Enable:  true,
+		Type:    "BASIC",
+		Updated: "2024-04-20 10:00:00.000Z",
+		Expand: model.TriggerConditionExpand{
+			Trigger: model.Trigger{
+				Id:             "trigger00000001",
+				OrganizationId: benchmarkOrganizationId,
+				Name:           "/folder/donation",
+				Code:           benchmarkTriggerCode,
+				Enable:         true,
+				Updated:        "2024-04-20 10:00:00.000Z",
+			},
+		},
+	}
+
+	// the organization is already in the cache, nothing is asked to PocketBase
+	app.cache[benchmarkOrganizationId] = cacheEntry{
+		cache: app.newOrganizationCache(benchmarkOrganizationId, nil, shareds),
+	}
+
+	return app, shareds, condition
+}
+
+func newBenchmarkEvent() *model.EventReceived {
+	payload, _ := json.Marshal(map[string]any{
+		"amount": 42,
+		"user":   map[string]any{"id": "u1", "name": " Streamer "},
+		"items": []map[string]any{
+			{"price": 5, "quantity": 2},
+			{"price": 12, "quantity": 1},
+		},
+	})
+
+	return &model.EventReceived{
+		Id: "event0000000001",
+		Event: model.Event{
+			OrganizationId: benchmarkOrganizationId,
+			Name:           "donation",
+			Payload:        payload,
+		},
+	}
+}
+
+// runBenchmarkProcess run the condition then the trigger like a process
+func runBenchmarkProcess(b *testing.B, app *application, event *model.EventReceived, condition *model.TriggerCondition) {
+	vmContext, err := NewVMContext(app, "", event, condition)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	value, err := vmContext.runConditionCode(condition)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if !value.ToBoolean() {
+		b.Fatal("condition should match")
+	}
+
+	program, err := app.triggerProgram(&condition.Expand.Trigger)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if _, err := vmContext.runProgram(program); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkRunString is a fresh runtime parsing every shared into the global
+// scope, then the condition and the trigger, for each process
+func BenchmarkRunString(b *testing.B) {
+	_, shareds, condition := newBenchmarkApplication()
+	event := newBenchmarkEvent()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		vm := goja.New()
+
+		var payload any
+		_ = json.Unmarshal(event.Payload, &payload)
+		_ = vm.Set("event", map[string]any{"payload": payload})
+
+		for _, s := range shareds {
+			if _, err := vm.RunString(strings.ReplaceAll(s.Code, "export ", "")); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		if _, err := vm.RunString(withoutImports(condition.Code)); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := vm.RunString(withoutImports(condition.Expand.Trigger.Code)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func withoutImports(code string) string {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		if !strings.HasPrefix(line, "import ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BenchmarkPrecompiled create a runtime for each process and run the bundled
+// programs compiled once
+func BenchmarkPrecompiled(b *testing.B) {
+	app, _, condition := newBenchmarkApplication()
+	event := newBenchmarkEvent()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		runBenchmarkProcess(b, app, event, condition)
+	}
+}
+
+// BenchmarkPrecompiledParallel is the precompiled programs under concurrent
+// events, as the event service process them
+func BenchmarkPrecompiledParallel(b *testing.B) {
+	app, _, condition := newBenchmarkApplication()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		event := newBenchmarkEvent()
+		for pb.Next() {
+			runBenchmarkProcess(b, app, event, condition)
+		}
+	})
+}
+
+// BenchmarkNewRuntime is only the runtime setup of a process, the most a pool
+// of runtimes created ahead could save
+func BenchmarkNewRuntime(b *testing.B) {
+	app, _, condition := newBenchmarkApplication()
+	event := newBenchmarkEvent()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := NewVMContext(app, "", event, condition); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkBareRuntime is goja.New alone, what a pool of runtimes created
+// ahead hold : the rest of the setup bind the event and the process
+func BenchmarkBareRuntime(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		goja.New()
+	}
+}
+
+const benchmarkGlobalSharedCode = `
+function formatUser(user) {
+	return user.name.trim().toLowerCase() + "#" + user.id;
+}
+
+function sum(values) {
+	return values.reduce(function (acc, value) { return acc + value; }, 0);
+}
+
+var thresholds = { low: 10, medium: 100, high: 1000 };
+`
+
+// BenchmarkPrecompiledGlobals is a code without import, the shareds of its
+// folder are run in the global scope of each runtime before it. A pool of
+// runtimes with the shareds loaded would save their run
+func BenchmarkPrecompiledGlobals(b *testing.B) {
+	app, shareds, condition := newBenchmarkApplication()
+	event := newBenchmarkEvent()
+
+	for _, s := range shareds {
+		s.Code = benchmarkGlobalSharedCode
+	}
+	app.cache[benchmarkOrganizationId] = cacheEntry{
+		cache: app.newOrganizationCache(benchmarkOrganizationId, nil, shareds),
+	}
+
+	condition.Code = withoutImports(condition.Code)
+	condition.Expand.Trigger.Code = withoutImports(condition.Expand.Trigger.Code)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		runBenchmarkProcess(b, app, event, condition)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/script"
+	"path"
+	"slices"
+	"strings"
+)
+
+const sharedNamespace = "shared"
+
+// bundleScript resolve the imports of a trigger or condition code against the
+// shareds of the organization : import { randomNumber } from '/example/board/utils'
+// or from './utils' relative to the trigger folder. Every module keep its own
+// scope, only what it export can be imported. The bundle is an iife, its last
+// expression is returned so it is still the value of the condition
+func bundleScript(name string, code string, shareds map[string]*model.Shared) (string, error) {
+	bundle, err := buildScript(name, code, shareds, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return returnLastExpression(bundle)
+}
+
+// bundleGlobalShared bundle a shared run in the global scope of a code without
+// import, what the shared export become globals like its plain declarations
+// did before the imports
+func bundleGlobalShared(s *model.Shared, shareds map[string]*model.Shared) (string, error) {
+	return buildScript(
+		s.Name,
+		script.Code(s.Language, s.Code, s.CompiledCode),
+		shareds,
+		"__shared",
+		map[string]string{"js": "Object.assign(globalThis, __shared);"},
+	)
+}
+
+func buildScript(name string, code string, shareds map[string]*model.Shared, globalName string, footer map[string]string) (string, error) {
+	plugin := api.Plugin{
+		Name: "shareds",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: ".*"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				sharedPath, err := resolveSharedPath(args.Importer, args.Path)
+				if err != nil {
+					return api.OnResolveResult{}, err
+				}
+
+				s, ok := shareds[sharedPath]
+				if !ok {
+					return api.OnResolveResult{}, fmt.Errorf("shared %s not found", sharedPath)
+				}
+				if !s.Enable {
+					return api.OnResolveResult{}, fmt.Errorf("shared %s is disabled", sharedPath)
+				}
+
+				return api.OnResolveResult{Path: sharedPath, Namespace: sharedNamespace}, nil
+			})
+
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: sharedNamespace}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				s := shareds[args.Path]
+				code := script.Code(s.Language, s.Code, s.CompiledCode)
+				return api.OnLoadResult{Contents: &code, Loader: api.LoaderJS}, nil
+			})
+		},
+	}
+
+	result := api.Build(api.BuildOptions{
+		Stdin: &api.StdinOptions{
+			Contents:   code,
+			Sourcefile: name,
+			Loader:     api.LoaderJS,
+		},
+		Bundle:   true,
+		Write:    false,
+		Platform: api.PlatformNeutral,
+		// an iife never touch the module global of the trigger runtime
+		Format:      api.FormatIIFE,
+		GlobalName:  globalName,
+		Footer:      footer,
+		Target:      api.ES2017,
+		TreeShaking: api.TreeShakingFalse,
+		Metafile:    true,
+		Plugins:     []api.Plugin{plugin},
+		LogLevel:    api.LogLevelSilent,
+	})
+
+	if len(result.Errors) > 0 {
+		return "", bundleError(result.Errors)
+	}
+
+	if err := checkImportCycle(result.Metafile); err != nil {
+		return "", err
+	}
+
+	if len(result.OutputFiles) == 0 {
+		return "", errors.New("empty bundle")
+	}
+
+	return string(result.OutputFiles[0].Contents), nil
+}
+
+// isModuleScript tell if code use import or export, a plain script is run like
+// before the imports with the shareds of its folders in the global scope
+func isModuleScript(name string, code string) (bool, error) {
+	result := api.Build(api.BuildOptions{
+		Stdin: &api.StdinOptions{
+			Contents:   code,
+			Sourcefile: name,
+			Loader:     api.LoaderJS,
+		},
+		Write:    false,
+		Platform: api.PlatformNeutral,
+		Metafile: true,
+		LogLevel: api.LogLevelSilent,
+	})
+
+	if len(result.Errors) > 0 {
+		return false, bundleError(result.Errors)
+	}
+
+	var meta bundleMetafile
+	if err := json.Unmarshal([]byte(result.Metafile), &meta); err != nil {
+		return false, err
+	}
+
+	return meta.Inputs[name].Format == "esm", nil
+}
+
+// returnLastExpression make the iife return the last expression statement of
+// the code, a script is the value of its last expression but a function isn't
+func returnLastExpression(bundle string) (string, error) {
+	program, err := parser.ParseFile(nil, "", bundle, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if len(program.Body) == 0 {
+		return bundle, nil
+	}
+
+	statement, ok := program.Body[len(program.Body)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return bundle, nil
+	}
+
+	call, ok := statement.Expression.(*ast.CallExpression)
+	if !ok {
+		return bundle, nil
+	}
+
+	iife, ok := call.Callee.(*ast.ArrowFunctionLiteral)
+	if !ok {
+		return bundle, nil
+	}
+
+	body, ok := iife.Body.(*ast.BlockStatement)
+	if !ok || len(body.List) == 0 {
+		return bundle, nil
+	}
+
+	last, ok := body.List[len(body.List)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return bundle, nil
+	}
+
+	offset := int(last.Idx0()) - 1
+	return bundle[:offset] + "return " + bundle[offset:], nil
+}
+
+// resolveSharedPath turn an import into a shared name, relative imports
+// start from the folder of the importer
+func resolveSharedPath(importer string, specifier string) (string, error) {
+	switch {
+	case strings.HasPrefix(specifier, "/"):
+		return path.Clean(specifier), nil
+	case strings.HasPrefix(specifier, "./"), strings.HasPrefix(specifier, "../"):
+		return path.Join(path.Dir(importer), specifier), nil
+	}
+
+	return "", fmt.Errorf("unknown module %q, a shared is imported by its path", specifier)
+}
+
+// bundleError point at the shared holding the error, not at the trigger
+func bundleError(messages []api.Message) error {
+	lines := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		text := strings.ReplaceAll(message.Text, `"`+sharedNamespace+":", `"`)
+		if message.Location != nil {
+			text = fmt.Sprintf(
+				"%s:%d:%d: %s",
+				strings.TrimPrefix(message.Location.File, sharedNamespace+":"),
+				message.Location.Line,
+				message.Location.Column+1,
+				text,
+			)
+		}
+		lines = append(lines, text)
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+type bundleMetafile struct {
+	Inputs map[string]struct {
+		Imports []struct {
+			Path string `json:"path"`
+		} `json:"imports"`
+		Format string `json:"format"`
+	} `json:"inputs"`
+}
+
+// checkImportCycle refuse shareds importing each other, their values would
+// depend on the evaluation order
+func checkImportCycle(metafile string) error {
+	var meta bundleMetafile
+	if err := json.Unmarshal([]byte(metafile), &meta); err != nil {
+		return err
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(meta.Inputs))
+	var stack []string
+
+	var visit func(input string) error
+	visit = func(input string) error {
+		switch state[input] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for _, item := range append(stack[slices.Index(stack, input):], input) {
+				cycle = append(cycle, strings.TrimPrefix(item, sharedNamespace+":"))
+			}
+			return fmt.Errorf("import cycle : %s", strings.Join(cycle, " -> "))
+		}
+
+		state[input] = visiting
+		stack = append(stack, input)
+
+		for _, imported := range meta.Inputs[input].Imports {
+			if err := visit(imported.Path); err != nil {
+				return err
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[input] = visited
+
+		return nil
+	}
+
+	for input := range meta.Inputs {
+		if err := visit(input); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
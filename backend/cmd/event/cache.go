@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/matcher"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/pattern"
+	"github.com/nats-io/nats.go"
+	"log/slog"
+	"time"
+)
+
+// organizationCache hold what the event service need from PocketBase for an
+// organization : the enabled conditions indexed by their event name pattern,
+// with their trigger and parsed matcher, and the shareds by path
+type organizationCache struct {
+	conditions     *pattern.Index[*model.TriggerCondition]
+	matchers       map[string]*matcher.Matcher
+	shareds        map[string]*model.Shared
+	sharedsVersion string
+	loadedAt       time.Time
+}
+
+// cacheEntry keep the generation an organization was loaded at, a load that
+// started before an invalidation is never stored
+type cacheEntry struct {
+	cache      *organizationCache
+	generation uint64
+}
+
+// onCacheMessage drop the cache of an organization, cmd/api publish it when
+// a trigger, a condition or a shared change
+func (app *application) onCacheMessage(msg *nats.Msg) {
+	organizationId := string(msg.Data)
+
+	app.cacheMu.Lock()
+	defer app.cacheMu.Unlock()
+
+	entry := app.cache[organizationId]
+	app.cache[organizationId] = cacheEntry{generation: entry.generation + 1}
+}
+
+// getOrganizationCache load the organization on first use, the ttl is only a
+// safety net for invalidation messages lost during a reconnection
+func (app *application) getOrganizationCache(organizationId string) (*organizationCache, error) {
+	app.cacheMu.Lock()
+	entry := app.cache[organizationId]
+	app.cacheMu.Unlock()
+
+	if entry.cache != nil && time.Since(entry.cache.loadedAt) <= app.config.cacheTTL {
+		return entry.cache, nil
+	}
+
+	cache, err := app.loadOrganizationCache(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	app.cacheMu.Lock()
+	if current := app.cache[organizationId]; current.generation == entry.generation {
+		app.cache[organizationId] = cacheEntry{cache: cache, generation: entry.generation}
+	}
+	app.cacheMu.Unlock()
+
+	return cache, nil
+}
+
+func (app *application) loadOrganizationCache(organizationId string) (*organizationCache, error) {
+	conditions, err := app.pb.GetEnabledConditionsForOrganization(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	shareds, err := app.pb.GetSharedsForOrganization(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(conditions)*2+len(shareds))
+	for _, condition := range conditions {
+		keep[condition.Id] = true
+		keep[condition.Expand.Trigger.Id] = true
+	}
+	for _, s := range shareds {
+		keep[s.Id] = true
+	}
+	app.programs.prune(organizationId, keep)
+
+	return app.newOrganizationCache(organizationId, conditions, shareds), nil
+}
+
+func (app *application) newOrganizationCache(organizationId string, conditions []*model.TriggerCondition, shareds []*model.Shared) *organizationCache {
+	cache := &organizationCache{
+		conditions: pattern.NewIndex[*model.TriggerCondition](),
+		matchers:   make(map[string]*matcher.Matcher),
+		shareds:    make(map[string]*model.Shared, len(shareds)),
+		loadedAt:   time.Now(),
+	}
+
+	// any change of a shared must rebuild the programs importing it
+	version := sha256.New()
+	for _, s := range shareds {
+		cache.shareds[s.Name] = s
+		_, _ = fmt.Fprintf(version, "%s@%s|", s.Id, s.Updated)
+	}
+	cache.sharedsVersion = hex.EncodeToString(version.Sum(nil))
+
+	for _, condition := range conditions {
+		p, err := pattern.Parse(condition.Name)
+		if err == nil {
+			var m *matcher.Matcher
+			if m, err = matcher.Parse(condition.Matcher); err == nil {
+				cache.matchers[condition.Id] = m
+				cache.conditions.Add(p, condition)
+				continue
+			}
+		}
+
+		app.logger.Error(
+			"invalid condition",
+			slog.String("organization", organizationId),
+			slog.Group("condition",
+				slog.String("id", condition.Id),
+				slog.String("name", condition.Name),
+			),
+			"error",
+			err,
+		)
+	}
+
+	return cache
+}
+
+// getConditionsForEvent return the conditions whose name match the event
+func (app *application) getConditionsForEvent(organizationId string, eventName string) ([]*model.TriggerCondition, *organizationCache, error) {
+	cache, err := app.getOrganizationCache(organizationId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cache.conditions.Match(eventName), cache, nil
+}
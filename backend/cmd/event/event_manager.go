@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/evntboard/app/backend/internal/matcher"
 	"github.com/evntboard/app/backend/internal/model"
-	"github.com/evntboard/app/backend/utils"
+	"github.com/evntboard/app/backend/internal/realtime"
+	"github.com/evntboard/app/backend/internal/script"
 	"log/slog"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -28,16 +35,81 @@ func (app *application) logDebugProcess(event *model.EventReceived, condition *m
 	)
 }
 
-func (app *application) processEvent(event *model.EventReceived, condition *model.TriggerCondition) {
-	app.logDebugProcess(event, condition, "start process")
+// eventMatchData is the event as seen by the trigger code, for the matchers
+func eventMatchData(event *model.EventReceived) map[string]any {
+	var data map[string]any
+
+	msgJson, err := json.Marshal(event)
+	if err != nil {
+		return data
+	}
 
-	processRecordId, err := app.pb.CreateProcess(condition.Expand.Trigger.OrganizationId, event.Id, condition.Expand.Trigger.Id)
+	_ = json.Unmarshal(msgJson, &data)
 
+	return data
+}
+
+// runConditionCode run the condition code, a condition with only a matcher
+// already passed when it gets here
+func (vmContext *VMContext) runConditionCode(condition *model.TriggerCondition) (goja.Value, error) {
+	if strings.TrimSpace(script.Code(condition.Language, condition.Code, condition.CompiledCode)) == "" {
+		if m, _ := matcher.Parse(condition.Matcher); m != nil {
+			return vmContext.vm.ToValue(true), nil
+		}
+	}
+
+	program, err := vmContext.app.conditionProgram(condition)
 	if err != nil {
-		app.logDebugProcess(event, condition, "error start process")
-		return
+		return nil, err
+	}
+
+	return vmContext.runProgram(program)
+}
+
+func (app *application) startProcess(event *model.EventReceived, condition *model.TriggerCondition, redelivered bool) (string, error) {
+	replayId := ""
+	if event.Replay != nil {
+		replayId = event.Replay.Id
+	}
+
+	// a redelivered event may already have its process created before a crash
+	if redelivered {
+		process, err := app.pb.GetProcessByEventIdAndTriggerId(event.Id, condition.Expand.Trigger.Id, replayId)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+
+		if process != nil {
+			if process.EndAt != "" {
+				return "", nil
+			}
+			return process.Id, nil
+		}
+	}
+
+	app.logDebugProcess(event, condition, "start process")
+
+	if event.Replay == nil {
+		return app.pb.CreateProcess(condition.Expand.Trigger.OrganizationId, event.Id, condition.Expand.Trigger.Id)
+	}
+
+	// link the replay to the process of the original run, if the trigger had one
+	replayOf := ""
+	original, err := app.pb.GetProcessByEventIdAndTriggerId(event.Id, condition.Expand.Trigger.Id, "")
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	if original != nil {
+		replayOf = original.Id
 	}
 
+	return app.pb.CreateReplayProcess(condition.Expand.Trigger.OrganizationId, event.Id, condition.Expand.Trigger.Id, event.Replay, replayOf)
+}
+
+func (app *application) processEvent(processRecordId string, event *model.EventReceived, condition *model.TriggerCondition) {
 	vmContext, err := NewVMContext(
 		app,
 		processRecordId,
@@ -54,107 +126,135 @@ func (app *application) processEvent(event *model.EventReceived, condition *mode
 		return
 	}
 
-	switch condition.Type {
-	case "THROTTLE":
-		app.throttleDataMu.Lock()
-		defer app.throttleDataMu.Unlock()
-
-		throttle, ok := app.throttleData[condition.Expand.Trigger.Id+":"+condition.Name]
+	// cancel messages can reach the process while it waits for throttle / debounce
+	app.registerProcess(processRecordId, vmContext)
 
-		currentTimeout := time.Duration(condition.Timeout) * time.Millisecond
+	stopProcess := func() {
+		app.unregisterProcess(processRecordId)
+		app.logDebugProcess(event, condition, "stop process")
 
-		if !ok {
-			throttle = utils.NewThrottle(currentTimeout)
-			app.throttleData[condition.Expand.Trigger.Id+":"+condition.Name] = throttle
+		err := app.pb.StopProcess(processRecordId)
+		if err != nil {
+			app.logDebugProcess(event, condition, "error stop process")
 		}
+	}
+
+	stateKey := app.realtime.GetKeyForCondition(condition.Expand.Trigger.Id, condition.Id)
+	currentTimeout := time.Duration(condition.Timeout) * time.Millisecond
 
-		throttle.ScheduleAction(
+	conditionType := condition.Type
+
+	// a replay is a one shot run, it must not touch the live throttle / debounce state
+	if event.Replay != nil {
+		conditionType = "BASIC"
+	}
+
+	switch conditionType {
+	case "THROTTLE":
+		err = app.realtime.NewThrottle(stateKey, currentTimeout).ScheduleAction(
+			processRecordId,
 			func() {
 				app.processCondition(vmContext, processRecordId, event, condition)
 			},
-			func() {
-				app.logDebugProcess(event, condition, "stop process")
-
-				err = app.pb.StopProcess(processRecordId)
-				if err != nil {
-					app.logDebugProcess(event, condition, "error stop process")
-				}
-			},
+			stopProcess,
 		)
 
 	case "DEBOUNCE":
-		app.debounceDataMu.Lock()
-		defer app.debounceDataMu.Unlock()
-
-		debounce, ok := app.debounceData[condition.Expand.Trigger.Id+":"+condition.Name]
-		currentTimeout := time.Duration(condition.Timeout) * time.Millisecond
-
-		if !ok {
-			debounce = utils.NewDebounce(currentTimeout)
-			app.debounceData[condition.Expand.Trigger.Id+":"+condition.Name] = debounce
-		}
-
-		debounce.ScheduleAction(
+		err = app.realtime.NewDebounce(stateKey, currentTimeout).ScheduleAction(
+			processRecordId,
 			func() {
 				app.processCondition(vmContext, processRecordId, event, condition)
 			},
-			func() {
-				app.logDebugProcess(event, condition, "stop process")
-				err = app.pb.StopProcess(processRecordId)
-				if err != nil {
-					app.logDebugProcess(event, condition, "error stop process")
-				}
-			},
+			stopProcess,
 		)
 
 	case "BASIC":
 		app.processCondition(vmContext, processRecordId, event, condition)
 
 	default:
-		app.logDebugProcess(event, condition, "stop process")
-		err = app.pb.StopErrorProcess(processRecordId, err)
-		if err != nil {
-			app.logDebugProcess(event, condition, "error stop process")
-		}
+		err = fmt.Errorf("unknown condition type %s", conditionType)
+	}
+
+	if err != nil {
+		app.unregisterProcess(processRecordId)
+		app.stopFailedProcess(processRecordId, event, condition, err, false)
 	}
 }
 
-func (app *application) processCondition(vmContext *VMContext, processRecordId string, event *model.EventReceived, condition *model.TriggerCondition) {
-	if shareds, err := app.pb.GetSharedByPath(condition.Expand.Trigger.OrganizationId, condition.Expand.Trigger.Name); err == nil {
-		for _, s := range shareds {
-			if _, err := vmContext.vm.RunString(s.Code); err != nil {
-				app.logger.Debug(
-					"error executing shared",
-					slog.String("organization", event.OrganizationId),
-					slog.Group("event",
-						slog.String("id", event.Id),
-						slog.String("name", event.Name),
-					),
-					slog.Group("trigger",
-						slog.String("id", condition.Expand.Trigger.Id),
-						slog.String("name", condition.Expand.Trigger.Name),
-					),
-					slog.Group("condition",
-						slog.String("id", condition.Id),
-						slog.String("name", condition.Name),
-					),
-					slog.Group("shared",
-						slog.String("id", s.Id),
-						slog.String("name", s.Name),
-					),
-				)
-			}
+func (app *application) stopFailedProcess(processRecordId string, event *model.EventReceived, condition *model.TriggerCondition, err error, executed bool) {
+	var cancelErr *CancelError
+
+	switch {
+	case errors.As(err, &cancelErr):
+		app.logDebugProcess(event, condition, "cancel process")
+
+		if executed {
+			err = app.pb.StopCancelledExecutedProcess(processRecordId, cancelErr.UserId, cancelErr)
+		} else {
+			err = app.pb.StopCancelledProcess(processRecordId, cancelErr.UserId, cancelErr)
+		}
+
+	case errors.Is(err, ErrExecutionTimeout):
+		app.logDebugProcess(event, condition, "timeout process")
+
+		if executed {
+			err = app.pb.StopTimeoutExecutedProcess(processRecordId, err)
+		} else {
+			err = app.pb.StopTimeoutProcess(processRecordId, err)
+		}
+
+	default:
+		app.logDebugProcess(event, condition, "error process")
+
+		if executed {
+			err = app.pb.StopErrorExecutedProcess(processRecordId, err)
+		} else {
+			err = app.pb.StopErrorProcess(processRecordId, err)
 		}
 	}
 
-	value, err := vmContext.vm.RunString(condition.Code)
 	if err != nil {
-		app.logDebugProcess(event, condition, "stop condition process")
+		app.logDebugProcess(event, condition, "error stop process")
+	}
+}
 
-		err = app.pb.StopErrorProcess(processRecordId, err)
-		if err != nil {
-			app.logDebugProcess(event, condition, "error stop condition process")
-		}
+// cancelledBeforeStart tell if the api cancelled the process in the database,
+// it does when no event service answer the cancel request : a process created
+// but not registered yet. The record is already stopped, it is left as is
+func (app *application) cancelledBeforeStart(processRecordId string, event *model.EventReceived, condition *model.TriggerCondition) bool {
+	if processRecordId == "" {
+		return false
+	}
+
+	process, err := app.pb.GetProcess(processRecordId)
+	if err != nil || !process.Cancelled {
+		return false
+	}
+
+	app.logDebugProcess(event, condition, "process cancelled before start")
+
+	return true
+}
+
+func (app *application) processCondition(vmContext *VMContext, processRecordId string, event *model.EventReceived, condition *model.TriggerCondition) {
+	defer app.unregisterProcess(processRecordId)
+
+	// cancelled while waiting for throttle / debounce
+	if err := context.Cause(vmContext.ctx); err != nil {
+		app.stopFailedProcess(processRecordId, event, condition, err, false)
+		return
+	}
+
+	if app.cancelledBeforeStart(processRecordId, event, condition) {
+		return
+	}
+
+	value, err := vmContext.runWithTimeout(vmContext.maxExecutionTime(condition.MaxExecutionTime), func() (goja.Value, error) {
+		return vmContext.runConditionCode(condition)
+	})
+
+	if err != nil {
+		app.stopFailedProcess(processRecordId, event, condition, err, false)
 		return
 	}
 
@@ -173,44 +273,59 @@ func (app *application) processCondition(vmContext *VMContext, processRecordId s
 }
 
 func (app *application) processReaction(vmContext *VMContext, processRecordId string, event *model.EventReceived, condition *model.TriggerCondition) {
-	moduleObj := vmContext.vm.NewObject()
-
-	_ = moduleObj.Set("request", vmContext.vmModuleNameRequestCall)
-	_ = moduleObj.Set("notify", vmContext.vmModuleNameNotifyCall)
-	_ = vmContext.vm.Set("module", moduleObj)
-
-	storageObj := vmContext.vm.NewObject()
-
-	_ = storageObj.Set("set", vmContext.vmStorageSet)
-	_ = storageObj.Set("get", vmContext.vmStorageGet)
-
-	_ = vmContext.vm.Set("storage", storageObj)
+	vmContext.installTriggerGlobals()
 
 	trimTriggerChannel := strings.Trim(condition.Expand.Trigger.Channel, " \t\n")
 
 	if trimTriggerChannel != "" {
-		v, exist := app.channels[trimTriggerChannel]
-		if !exist {
-			app.channelsMu.Lock()
-			defer app.channelsMu.Unlock()
+		app.logDebugProcess(event, condition, "lock channel")
 
-			v = &sync.Mutex{}
-			app.channels[trimTriggerChannel] = v
+		lock, err := app.realtime.AcquireLock(vmContext.ctx, app.realtime.GetKeyForChannel(event.OrganizationId, trimTriggerChannel))
+		if err != nil {
+			app.logDebugProcess(event, condition, "error lock channel")
+			app.stopFailedProcess(processRecordId, event, condition, err, false)
+			return
 		}
-		app.logDebugProcess(event, condition, "lock channel")
-		v.Lock()
-	}
 
-	_ = vmContext.vm.Set("log", vmContext.vmLog)
-	_ = vmContext.vm.Set("sleep", vmContext.vmSleep)
+		// the trigger is cancelled if the lease is lost, it must not run next
+		// to the new owner of the channel
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-lock.Lost():
+				app.logDebugProcess(event, condition, "lost channel lock")
+				vmContext.cancel(realtime.ErrLockLost)
+			case <-stop:
+			}
+		}()
 
-	if _, err := vmContext.vm.RunString(condition.Expand.Trigger.Code); err != nil {
-		app.logDebugProcess(event, condition, "error execute trigger")
+		// released even if the trigger panic or timeout
+		defer func() {
+			app.logDebugProcess(event, condition, "unlock channel")
+
+			if err := lock.Release(); err != nil {
+				app.logDebugProcess(event, condition, "error unlock channel")
+			}
+		}()
+	}
+
+	if app.cancelledBeforeStart(processRecordId, event, condition) {
+		return
+	}
 
-		err = app.pb.StopErrorExecutedProcess(processRecordId, err)
+	_, err := vmContext.runWithTimeout(vmContext.maxExecutionTime(condition.Expand.Trigger.MaxExecutionTime), func() (goja.Value, error) {
+		program, err := app.triggerProgram(&condition.Expand.Trigger)
 		if err != nil {
-			app.logDebugProcess(event, condition, "error error execute trigger")
+			return nil, err
 		}
+
+		return vmContext.runProgram(program)
+	})
+
+	if err != nil {
+		app.logDebugProcess(event, condition, "error execute trigger")
+		app.stopFailedProcess(processRecordId, event, condition, err, true)
 	} else {
 		app.logDebugProcess(event, condition, "stop process")
 		err = app.pb.StopExecutedProcess(processRecordId)
@@ -218,9 +333,4 @@ func (app *application) processReaction(vmContext *VMContext, processRecordId st
 			app.logDebugProcess(event, condition, "error stop process")
 		}
 	}
-
-	if trimTriggerChannel != "" {
-		v := app.channels[trimTriggerChannel]
-		v.Unlock()
-	}
 }
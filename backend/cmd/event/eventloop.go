@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"log/slog"
+	"time"
+)
+
+type eventLoopJob func() error
+
+type vmTimer struct {
+	timer    *time.Timer
+	fn       goja.Callable
+	args     []goja.Value
+	delay    time.Duration
+	interval bool
+	cleared  bool
+}
+
+// every field is only touched from the goroutine running the script, async
+// work done elsewhere comes back as a job through the jobs channel
+type eventLoop struct {
+	jobs       chan eventLoopJob
+	pending    int
+	intervals  int
+	timers     map[int64]*vmTimer
+	nextTimer  int64
+	rejections map[*goja.Promise]struct{}
+}
+
+func newEventLoop() *eventLoop {
+	return &eventLoop{
+		jobs:       make(chan eventLoopJob),
+		timers:     make(map[int64]*vmTimer),
+		rejections: make(map[*goja.Promise]struct{}),
+	}
+}
+
+func (vmContext *VMContext) installEventLoop() {
+	vmContext.vm.SetPromiseRejectionTracker(func(p *goja.Promise, operation goja.PromiseRejectionOperation) {
+		if operation == goja.PromiseRejectionReject {
+			vmContext.loop.rejections[p] = struct{}{}
+		} else {
+			delete(vmContext.loop.rejections, p)
+		}
+	})
+
+	_ = vmContext.vm.Set("setTimeout", func(call goja.FunctionCall) goja.Value {
+		return vmContext.vmSetTimer(call, false)
+	})
+	_ = vmContext.vm.Set("setInterval", func(call goja.FunctionCall) goja.Value {
+		return vmContext.vmSetTimer(call, true)
+	})
+	_ = vmContext.vm.Set("clearTimeout", vmContext.vmClearTimer)
+	_ = vmContext.vm.Set("clearInterval", vmContext.vmClearTimer)
+}
+
+// runProgram runs program then keeps the loop alive until every timeout and
+// async module request is done, a returned Promise is settled before being
+// returned. Intervals only keep it alive while that Promise is pending
+func (vmContext *VMContext) runProgram(program *scriptProgram) (goja.Value, error) {
+	ctx := vmContext.ctx
+	loop := vmContext.loop
+
+	// jobs left by a previous script are never read again
+	loop.jobs = make(chan eventLoopJob)
+	loop.pending = 0
+	loop.intervals = 0
+	clear(loop.timers)
+	clear(loop.rejections)
+
+	vmContext.runGlobalShareds(program.globals)
+
+	value, err := vmContext.vm.RunProgram(program.program)
+	if err != nil {
+		return nil, err
+	}
+
+	for loop.pending > 0 {
+		// an interval never cleared would keep the script until its max
+		// execution time, it stop once nothing else is left to wait for
+		if loop.pending == loop.intervals && !pendingPromise(value) {
+			vmContext.stopTimers()
+			break
+		}
+
+		select {
+		case job := <-loop.jobs:
+			loop.pending--
+			if err := job(); err != nil {
+				return nil, err
+			}
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+
+	for p := range loop.rejections {
+		return nil, fmt.Errorf("unhandled promise rejection : %s", p.Result())
+	}
+
+	if p, ok := value.Export().(*goja.Promise); ok {
+		switch p.State() {
+		case goja.PromiseStateFulfilled:
+			return p.Result(), nil
+		case goja.PromiseStateRejected:
+			return nil, fmt.Errorf("promise rejected : %s", p.Result())
+		default:
+			return nil, errors.New("promise never settled")
+		}
+	}
+
+	return value, nil
+}
+
+// runGlobalShareds run once per runtime the shareds of a code without import,
+// a failing shared is only logged and the code still run
+func (vmContext *VMContext) runGlobalShareds(globals []*globalShared) {
+	for _, global := range globals {
+		if vmContext.globalsLoaded[global.shared.Id] {
+			continue
+		}
+		vmContext.globalsLoaded[global.shared.Id] = true
+
+		err := global.err
+		if err == nil {
+			_, err = vmContext.vm.RunProgram(global.program)
+		}
+
+		if err != nil {
+			vmContext.app.logger.Debug(
+				"error executing shared",
+				slog.String("organization", vmContext.event.OrganizationId),
+				slog.Group("shared",
+					slog.String("id", global.shared.Id),
+					slog.String("name", global.shared.Name),
+				),
+				"error",
+				err,
+			)
+		}
+	}
+}
+
+func pendingPromise(value goja.Value) bool {
+	if value == nil {
+		return false
+	}
+	p, ok := value.Export().(*goja.Promise)
+	return ok && p.State() == goja.PromiseStatePending
+}
+
+func (vmContext *VMContext) stopTimers() {
+	for id, t := range vmContext.loop.timers {
+		t.cleared = true
+		t.timer.Stop()
+		delete(vmContext.loop.timers, id)
+	}
+	vmContext.loop.intervals = 0
+}
+
+// async starts fn outside the loop, then runs its callback on the loop
+func (vmContext *VMContext) async(fn func(ctx context.Context) eventLoopJob) {
+	ctx := vmContext.ctx
+	jobs := vmContext.loop.jobs
+	vmContext.loop.pending++
+
+	go func() {
+		job := fn(ctx)
+
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (vmContext *VMContext) vmSetTimer(call goja.FunctionCall, interval bool) goja.Value {
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(vmContext.vm.NewTypeError("first argument must be a function"))
+	}
+
+	delay := time.Duration(call.Argument(1).ToInteger()) * time.Millisecond
+	if delay < 0 {
+		delay = 0
+	}
+
+	var args []goja.Value
+	if len(call.Arguments) > 2 {
+		args = call.Arguments[2:]
+	}
+
+	loop := vmContext.loop
+	loop.nextTimer++
+	id := loop.nextTimer
+
+	t := &vmTimer{
+		fn:       fn,
+		args:     args,
+		delay:    delay,
+		interval: interval,
+	}
+	loop.timers[id] = t
+	if interval {
+		loop.intervals++
+	}
+
+	vmContext.scheduleTimer(id, t)
+
+	return vmContext.vm.ToValue(id)
+}
+
+func (vmContext *VMContext) scheduleTimer(id int64, t *vmTimer) {
+	ctx := vmContext.ctx
+	jobs := vmContext.loop.jobs
+	vmContext.loop.pending++
+
+	t.timer = time.AfterFunc(t.delay, func() {
+		job := func() error {
+			if t.cleared {
+				return nil
+			}
+
+			if t.interval {
+				vmContext.scheduleTimer(id, t)
+			} else {
+				delete(vmContext.loop.timers, id)
+			}
+
+			_, err := t.fn(goja.Undefined(), t.args...)
+			return err
+		}
+
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+		}
+	})
+}
+
+func (vmContext *VMContext) vmClearTimer(id int64) {
+	t, ok := vmContext.loop.timers[id]
+	if !ok {
+		return
+	}
+
+	t.cleared = true
+	delete(vmContext.loop.timers, id)
+	if t.interval {
+		vmContext.loop.intervals--
+	}
+
+	// a timer already fired still sends its job, the job handle the pending count
+	if t.timer.Stop() {
+		vmContext.loop.pending--
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const httpMaxRedirects = 5
+
+type httpRequest struct {
+	Url     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type httpResponse struct {
+	Url     string            `json:"url"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// fetch(url, { method, headers, body }) return a Promise
+func (vmContext *VMContext) vmFetch(rawUrl string, options map[string]any) *goja.Promise {
+	promise, resolve, reject := vmContext.vm.NewPromise()
+
+	request, err := newHttpRequest(rawUrl, options)
+	if err != nil {
+		reject(vmContext.vm.NewGoError(err))
+		return promise
+	}
+
+	vmContext.async(func(ctx context.Context) eventLoopJob {
+		response, err := vmContext.httpRequest(ctx, request)
+
+		return func() error {
+			if err != nil {
+				reject(vmContext.vm.NewGoError(err))
+			} else {
+				resolve(vmContext.httpResponseValue(response))
+			}
+			return nil
+		}
+	})
+
+	return promise
+}
+
+// http.request({ url, method, headers, body }) block until the response
+func (vmContext *VMContext) vmHttpRequest(options map[string]any) goja.Value {
+	rawUrl, _ := options["url"].(string)
+
+	request, err := newHttpRequest(rawUrl, options)
+	if err != nil {
+		panic(vmContext.vm.NewGoError(err))
+	}
+
+	response, err := vmContext.httpRequest(vmContext.ctx, request)
+	if err != nil {
+		panic(vmContext.vm.NewGoError(err))
+	}
+
+	return vmContext.httpResponseValue(response)
+}
+
+func newHttpRequest(rawUrl string, options map[string]any) (*httpRequest, error) {
+	request := &httpRequest{
+		Url:     rawUrl,
+		Method:  http.MethodGet,
+		Headers: make(map[string]string),
+	}
+
+	if method, ok := options["method"].(string); ok && method != "" {
+		request.Method = strings.ToUpper(method)
+	}
+
+	if headers, ok := options["headers"].(map[string]any); ok {
+		for key, value := range headers {
+			request.Headers[key] = fmt.Sprint(value)
+		}
+	}
+
+	switch body := options["body"].(type) {
+	case nil:
+	case string:
+		request.Body = body
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = string(data)
+
+		if !hasHeader(request.Headers, "Content-Type") {
+			request.Headers["Content-Type"] = "application/json"
+		}
+	}
+
+	return request, nil
+}
+
+// redacted hide credentials before the request is stored in the process history
+func (request *httpRequest) redacted() *httpRequest {
+	headers := make(map[string]string, len(request.Headers))
+
+	for key, value := range request.Headers {
+		switch strings.ToLower(key) {
+		case "authorization", "proxy-authorization", "cookie", "x-api-key":
+			headers[key] = "[redacted]"
+		default:
+			headers[key] = value
+		}
+	}
+
+	return &httpRequest{
+		Url:     request.Url,
+		Method:  request.Method,
+		Headers: headers,
+		Body:    request.Body,
+	}
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (vmContext *VMContext) httpResponseValue(response *httpResponse) goja.Value {
+	obj := vmContext.vm.NewObject()
+
+	_ = obj.Set("url", response.Url)
+	_ = obj.Set("status", response.Status)
+	_ = obj.Set("ok", response.Status >= 200 && response.Status < 300)
+	_ = obj.Set("headers", response.Headers)
+	_ = obj.Set("body", response.Body)
+	_ = obj.Set("text", func() string {
+		return response.Body
+	})
+	_ = obj.Set("json", func() any {
+		var value any
+		if err := json.Unmarshal([]byte(response.Body), &value); err != nil {
+			panic(vmContext.vm.NewGoError(err))
+		}
+		return value
+	})
+
+	return obj
+}
+
+// httpRequest only reach the organization allowed hosts, every call is
+// recorded in the process requests
+func (vmContext *VMContext) httpRequest(ctx context.Context, request *httpRequest) (*httpResponse, error) {
+	allowedHosts, err := vmContext.httpAllowedHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(request.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported protocol %q", target.Scheme)
+	}
+
+	if !isHostAllowed(allowedHosts, target.Hostname()) {
+		return nil, fmt.Errorf("host %s is not allowed for this organization", target.Hostname())
+	}
+
+	if vmContext.transcript != nil || vmContext.dryRun() {
+		if vmContext.transcript != nil {
+			vmContext.transcript.call("http", "", fmt.Sprintf("%s %s", request.Method, request.Url), request.redacted())
+		} else if err := vmContext.dryRunRequest("", fmt.Sprintf("%s %s", request.Method, request.Url), request.redacted(), false); err != nil {
+			return nil, err
+		}
+
+		return &httpResponse{
+			Url:     request.Url,
+			Status:  http.StatusNoContent,
+			Headers: make(map[string]string),
+		}, nil
+	}
+
+	processRequestRecordId, err := vmContext.app.pb.CreateProcessRequest(
+		vmContext.processRecordId,
+		"",
+		fmt.Sprintf("%s %s", request.Method, request.Url),
+		request.redacted(),
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error create process request http request : %s", err.Error())
+	}
+
+	response, err := vmContext.doHttpRequest(ctx, request, allowedHosts)
+	if err != nil {
+		msg, _ := json.Marshal(err.Error())
+		_ = vmContext.app.pb.UpdateErrorProcessRequest(processRequestRecordId, msg)
+		return nil, err
+	}
+
+	msg, _ := json.Marshal(response)
+	_ = vmContext.app.pb.UpdateSuccessProcessRequest(processRequestRecordId, msg)
+
+	return response, nil
+}
+
+func (vmContext *VMContext) doHttpRequest(ctx context.Context, request *httpRequest, allowedHosts []string) (*httpResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, vmContext.app.config.httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.Url, strings.NewReader(request.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= httpMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if !isHostAllowed(allowedHosts, req.URL.Hostname()) {
+				return fmt.Errorf("redirect to host %s is not allowed for this organization", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	maxSize := vmContext.app.config.httpMaxResponseSize
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("response body exceed %d bytes", maxSize)
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for key, values := range resp.Header {
+		headers[strings.ToLower(key)] = strings.Join(values, ", ")
+	}
+
+	return &httpResponse{
+		Url:     resp.Request.URL.String(),
+		Status:  resp.StatusCode,
+		Headers: headers,
+		Body:    string(body),
+	}, nil
+}
+
+func (vmContext *VMContext) httpAllowedHosts() ([]string, error) {
+	vmContext.httpHostsOnce.Do(func() {
+		organization, err := vmContext.app.pb.GetOrganizationById(vmContext.event.OrganizationId)
+		if err != nil {
+			vmContext.httpHostsErr = err
+			return
+		}
+		vmContext.httpHosts = organization.HttpAllowedHosts
+	})
+
+	return vmContext.httpHosts, vmContext.httpHostsErr
+}
+
+// isHostAllowed match exact hosts and "*.example.com" for any sub domain
+func isHostAllowed(allowedHosts []string, host string) bool {
+	host = strings.ToLower(host)
+
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+
+		if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
+	"sync"
+)
+
+// instanceBalancer spread the requests to a module on its instances in turn,
+// the turn is kept per module
+type instanceBalancer struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func newInstanceBalancer() *instanceBalancer {
+	return &instanceBalancer{
+		next: make(map[string]int),
+	}
+}
+
+// pick return the session of the instance to call, a detached instance is
+// used only when none is connected : it keep the request until its module resume
+func (b *instanceBalancer) pick(module *model.Module) (string, error) {
+	var connected, detached []string
+	for _, instance := range module.Expand.Instances {
+		if instance.Status == model.ModuleInstanceStatusDetached {
+			detached = append(detached, instance.Session)
+		} else {
+			connected = append(connected, instance.Session)
+		}
+	}
+
+	candidates := connected
+	if len(candidates) == 0 {
+		candidates = detached
+	}
+
+	if len(candidates) == 0 {
+		return "", errors.New("no instance connected")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	index := b.next[module.Id] % len(candidates)
+	b.next[module.Id] = index + 1
+
+	return candidates[index], nil
+}
+
+// moduleSessions return the session of every instance, for a broadcast
+func moduleSessions(module *model.Module) []string {
+	sessions := make([]string, 0, len(module.Expand.Instances))
+	for _, instance := range module.Expand.Instances {
+		sessions = append(sessions, instance.Session)
+	}
+	return sessions
+}
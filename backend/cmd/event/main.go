@@ -6,12 +6,14 @@ import (
 	"github.com/evntboard/app/backend/internal/env"
 	"github.com/evntboard/app/backend/internal/model"
 	"github.com/evntboard/app/backend/internal/realtime"
-	"github.com/evntboard/app/backend/utils"
 	"github.com/nats-io/nats.go"
 	"log/slog"
 	"os"
+	"os/signal"
 	"runtime/debug"
 	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -31,6 +33,10 @@ type config struct {
 	pocketBaseURL           string
 	pocketBaseAdminEmail    string
 	pocketBaseAdminPassword string
+	maxExecutionTime        time.Duration
+	httpTimeout             time.Duration
+	httpMaxResponseSize     int64
+	cacheTTL                time.Duration
 }
 
 type application struct {
@@ -39,14 +45,14 @@ type application struct {
 	config   config
 	logger   *slog.Logger
 
-	throttleData   map[string]*utils.Throttle
-	throttleDataMu *sync.Mutex
+	processes   map[string]*VMContext
+	processesMu sync.RWMutex
 
-	debounceData   map[string]*utils.Debounce
-	debounceDataMu *sync.Mutex
+	cache   map[string]cacheEntry
+	cacheMu sync.Mutex
 
-	channels   map[string]*sync.Mutex
-	channelsMu *sync.Mutex
+	programs  *programCache
+	instances *instanceBalancer
 }
 
 func run(logger *slog.Logger) error {
@@ -57,35 +63,33 @@ func run(logger *slog.Logger) error {
 	cfg.pocketBaseAdminPassword = env.GetString("POCKETBASE_ADMIN_PASSWORD", "admin")
 	cfg.natsUrl = env.GetString("NATS_URL", nats.DefaultURL)
 	cfg.natsQueueName = env.GetString("NAME", "events")
+	cfg.maxExecutionTime = time.Duration(env.GetInt("MAX_EXECUTION_TIME", 900000)) * time.Millisecond
+	cfg.httpTimeout = time.Duration(env.GetInt("HTTP_TIMEOUT", 10000)) * time.Millisecond
+	cfg.httpMaxResponseSize = int64(env.GetInt("HTTP_MAX_RESPONSE_SIZE", 1<<20))
+	cfg.cacheTTL = time.Duration(env.GetInt("CACHE_TTL", 300000)) * time.Millisecond
 
 	app := &application{
-		config:         cfg,
-		logger:         logger,
-		realtime:       realtime.NewRealtimeClient(cfg.natsUrl),
-		pb:             database.NewPocketBaseClient(cfg.pocketBaseURL, cfg.pocketBaseAdminEmail, cfg.pocketBaseAdminPassword),
-		throttleData:   make(map[string]*utils.Throttle),
-		throttleDataMu: &sync.Mutex{},
-		debounceData:   make(map[string]*utils.Debounce),
-		debounceDataMu: &sync.Mutex{},
-		channels:       make(map[string]*sync.Mutex),
-		channelsMu:     &sync.Mutex{},
+		config:    cfg,
+		logger:    logger,
+		realtime:  realtime.NewRealtimeClient(cfg.natsUrl),
+		pb:        database.NewPocketBaseClient(cfg.pocketBaseURL, cfg.pocketBaseAdminEmail, cfg.pocketBaseAdminPassword),
+		processes: make(map[string]*VMContext),
+		cache:     make(map[string]cacheEntry),
+		programs:  newProgramCache(),
+		instances: newInstanceBalancer(),
 	}
 
 	funcOnMsg := func(msg *nats.Msg) {
 		var event *model.EventReceived
 
-		// just omit event not valid :)
+		// event not valid will never be : terminate it to avoid redelivery
 		if err := json.Unmarshal(msg.Data, &event); err != nil {
 			app.logger.Error(
 				"error unmarshal event",
-				slog.Group("event",
-					slog.String("id", event.Id),
-					slog.String("name", event.Name),
-					slog.String("organization", event.OrganizationId),
-				),
 				"error",
 				err,
 			)
+			_ = msg.Term()
 			return
 		}
 
@@ -98,28 +102,114 @@ func run(logger *slog.Logger) error {
 			),
 		)
 
-		conditions, err := app.pb.GetConditionsForOrganizationAndEventName(event.OrganizationId, event.Name)
+		redelivered := false
+		if metadata, err := msg.Metadata(); err == nil {
+			redelivered = metadata.NumDelivered > 1
+		}
+
+		conditions, cache, err := app.getConditionsForEvent(event.OrganizationId, event.Name)
 
 		if err != nil {
+			app.logger.Error(
+				"error get conditions",
+				slog.Group("event",
+					slog.String("id", event.Id),
+					slog.String("name", event.Name),
+					slog.String("organization", event.OrganizationId),
+				),
+				"error",
+				err,
+			)
+			_ = msg.NakWithDelay(realtime.EventNakDelay)
 			return
 		}
 
+		processes := make(map[string]*model.TriggerCondition)
+		matchData := eventMatchData(event)
+
 		for _, condition := range conditions {
+			if event.Replay != nil && event.Replay.TriggerId != "" && event.Replay.TriggerId != condition.Expand.Trigger.Id {
+				continue
+			}
+
+			// the declarative matcher run without a VM, no process when it doesn't match
+			if m := cache.matchers[condition.Id]; m != nil && !m.Match(matchData) {
+				continue
+			}
+
+			processRecordId, err := app.startProcess(event, condition, redelivered)
+
+			if err != nil {
+				app.logDebugProcess(event, condition, "error start process")
+				_ = msg.NakWithDelay(realtime.EventNakDelay)
+				return
+			}
+
+			if processRecordId != "" {
+				processes[processRecordId] = condition
+			}
+		}
+
+		// all event_processes exist now, the event can be removed from the stream
+		if err := msg.Ack(); err != nil {
+			app.logger.Error(
+				"error ack event",
+				slog.Group("event",
+					slog.String("id", event.Id),
+					slog.String("name", event.Name),
+					slog.String("organization", event.OrganizationId),
+				),
+				"error",
+				err,
+			)
+		}
+
+		for processRecordId, condition := range processes {
 			go app.processEvent(
+				processRecordId,
 				event,
 				condition,
 			)
 		}
 	}
 
-	if _, err := app.realtime.Subscribe(cfg.natsQueueName, funcOnMsg); err != nil {
-		app.logger.Error(
-			"error subscribe queue",
-			slog.String("error", err.Error()),
-		)
+	if err := app.realtime.EnsureEventStream(); err != nil {
+		return err
+	}
+
+	if err := app.realtime.EnsureEventStateBuckets(); err != nil {
+		return err
+	}
+
+	if _, err := app.realtime.Subscribe(app.realtime.GetChannelForAllProcesses(), app.onProcessMessage); err != nil {
+		return err
+	}
+
+	// every replica hold its own cache
+	if _, err := app.realtime.Subscribe(app.realtime.GetChannelForAllOrganizationCaches(), app.onCacheMessage); err != nil {
+		return err
 	}
 
-	select {}
+	// one replica answer each test request
+	if _, err := app.realtime.QueueSubscribe(app.realtime.GetChannelForTriggerTest(), cfg.natsQueueName, app.onTriggerTestMessage); err != nil {
+		return err
+	}
+
+	sub, err := app.realtime.SubscribeEvents(cfg.natsQueueName, funcOnMsg)
+	if err != nil {
+		return err
+	}
+
+	quitChan := make(chan os.Signal, 1)
+	signal.Notify(quitChan, syscall.SIGINT, syscall.SIGTERM)
+	<-quitChan
+
+	app.logger.Info("stopping event service")
+
+	// let the in flight messages be acknowledged before leaving
+	if err := sub.Drain(); err != nil {
+		return err
+	}
 
-	return nil
+	return app.realtime.Drain()
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/schema"
+	"strings"
+)
+
+// validateModuleCall refuse a method the module manifest doesn't declare or
+// params that doesn't match its schema, a module without manifest accept all
+func validateModuleCall(module *model.Module, method string, params any) error {
+	if module.Manifest == nil {
+		return nil
+	}
+
+	var declared *model.ModuleManifestMethod
+	for i := range module.Manifest.Methods {
+		if module.Manifest.Methods[i].Name == method {
+			declared = &module.Manifest.Methods[i]
+			break
+		}
+	}
+
+	if declared == nil {
+		return fmt.Errorf("module %s has no method %s", module.Name, method)
+	}
+
+	if len(declared.Params) == 0 || string(declared.Params) == "null" {
+		return nil
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error encoding json : %s", err.Error())
+	}
+
+	schemaErrors, err := schema.Validate(declared.Params, data)
+	if err != nil {
+		return fmt.Errorf("invalid params schema for %s.%s : %s", module.Name, method, err.Error())
+	}
+
+	if len(schemaErrors) > 0 {
+		return fmt.Errorf("params doesn't match %s.%s : %s", module.Name, method, strings.Join(schemaErrors, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/nats-io/nats.go"
+	"log/slog"
+	"strings"
+)
+
+type ProcessMessage struct {
+	Type    string `json:"type"`
+	Action  string `json:"action"`
+	Payload struct {
+		User string `json:"user"`
+	} `json:"payload"`
+}
+
+func (app *application) registerProcess(processRecordId string, vmContext *VMContext) {
+	app.processesMu.Lock()
+	defer app.processesMu.Unlock()
+
+	app.processes[processRecordId] = vmContext
+}
+
+func (app *application) unregisterProcess(processRecordId string) {
+	app.processesMu.Lock()
+	defer app.processesMu.Unlock()
+
+	delete(app.processes, processRecordId)
+}
+
+func (app *application) getProcess(processRecordId string) *VMContext {
+	app.processesMu.RLock()
+	defer app.processesMu.RUnlock()
+
+	if vmContext, ok := app.processes[processRecordId]; ok {
+		return vmContext
+	}
+	return nil
+}
+
+func (app *application) onProcessMessage(msg *nats.Msg) {
+	processRecordId := strings.TrimPrefix(msg.Subject, "process.")
+
+	// the process is handled by another replica, let it answer
+	vmContext := app.getProcess(processRecordId)
+	if vmContext == nil {
+		return
+	}
+
+	var data ProcessMessage
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		app.logger.Error(
+			"error unmarshal process message",
+			slog.String("process", processRecordId),
+			"error",
+			err,
+		)
+		return
+	}
+
+	if data.Type != "process" || data.Action != "cancel" {
+		return
+	}
+
+	app.logger.Debug(
+		"cancel process",
+		slog.String("process", processRecordId),
+		slog.String("user", data.Payload.User),
+	)
+
+	vmContext.Cancel(data.Payload.User)
+
+	msgJson, err := json.Marshal(map[string]any{
+		"success": true,
+	})
+	if err != nil {
+		return
+	}
+
+	_ = msg.Respond(msgJson)
+}
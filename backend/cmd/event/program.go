@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/script"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// programCache keep the compiled code of the triggers and conditions, a
+// record is only bundled and compiled again once its version change
+type programCache struct {
+	mu       sync.Mutex
+	programs map[string]*cachedProgram
+}
+
+type cachedProgram struct {
+	organization string
+	version      string
+	program      *scriptProgram
+	err          error
+}
+
+// scriptProgram is a compiled trigger or condition. A code without import or
+// export is run like before the imports : the shareds of its folders are run
+// first in the global scope
+type scriptProgram struct {
+	program *goja.Program
+	globals []*globalShared
+}
+
+type globalShared struct {
+	shared  *model.Shared
+	program *goja.Program
+	err     error
+}
+
+func newProgramCache() *programCache {
+	return &programCache{
+		programs: make(map[string]*cachedProgram),
+	}
+}
+
+// get compile the first time a record id / version pair is seen, an error
+// is kept as well
+func (c *programCache) get(organizationId string, id string, version string, compile func() (*scriptProgram, error)) (*scriptProgram, error) {
+	c.mu.Lock()
+	cached, ok := c.programs[id]
+	c.mu.Unlock()
+
+	if ok && cached.version == version {
+		return cached.program, cached.err
+	}
+
+	program, err := compile()
+
+	c.mu.Lock()
+	c.programs[id] = &cachedProgram{
+		organization: organizationId,
+		version:      version,
+		program:      program,
+		err:          err,
+	}
+	c.mu.Unlock()
+
+	return program, err
+}
+
+// prune drop the programs of the organization whose record is gone or
+// disabled, keep hold the ids still in use
+func (c *programCache) prune(organizationId string, keep map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cached := range c.programs {
+		if cached.organization == organizationId && !keep[id] {
+			delete(c.programs, id)
+		}
+	}
+}
+
+// compileScript bundle the shareds imported by code then compile the result,
+// the version of the record and of the shareds form the cache key
+func (app *application) compileScript(organizationId string, id string, updated string, name string, code string) (*scriptProgram, error) {
+	cache, err := app.getOrganizationCache(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.programs.get(organizationId, id, updated+"|"+cache.sharedsVersion, func() (*scriptProgram, error) {
+		module, err := isModuleScript(name, code)
+		if err != nil {
+			return nil, err
+		}
+
+		if !module {
+			program, err := goja.Compile(name, code, false)
+			if err != nil {
+				return nil, err
+			}
+
+			return &scriptProgram{
+				program: program,
+				globals: app.globalShareds(organizationId, name, cache),
+			}, nil
+		}
+
+		bundle, err := bundleScript(name, code, cache.shareds)
+		if err != nil {
+			return nil, err
+		}
+
+		program, err := goja.Compile(name, bundle, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return &scriptProgram{program: program}, nil
+	})
+}
+
+// globalShareds compile the shareds directly in the folders above name, the
+// newest first. An error is only logged when the shared is run, like before
+func (app *application) globalShareds(organizationId string, name string, cache *organizationCache) []*globalShared {
+	var globals []*globalShared
+
+	for _, s := range cache.shareds {
+		if !isAncestorShared(s.Name, name) {
+			continue
+		}
+
+		shared, err := app.programs.get(organizationId, s.Id, s.Updated+"|"+cache.sharedsVersion, func() (*scriptProgram, error) {
+			code := script.Code(s.Language, s.Code, s.CompiledCode)
+
+			module, err := isModuleScript(s.Name, code)
+			if err == nil && module {
+				code, err = bundleGlobalShared(s, cache.shareds)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			program, err := goja.Compile(s.Name, code, false)
+			if err != nil {
+				return nil, err
+			}
+
+			return &scriptProgram{program: program}, nil
+		})
+
+		global := &globalShared{shared: s, err: err}
+		if err == nil {
+			global.program = shared.program
+		}
+
+		globals = append(globals, global)
+	}
+
+	slices.SortFunc(globals, func(a, b *globalShared) int {
+		return strings.Compare(b.shared.Created, a.shared.Created)
+	})
+
+	return globals
+}
+
+// isAncestorShared tell if the shared is directly in one of the folders of
+// name, /a/utils is run for /a/b/trigger but /a/c/utils isn't
+func isAncestorShared(sharedName string, name string) bool {
+	folder := path.Dir(sharedName)
+
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		if dir == folder {
+			return true
+		}
+		if dir == "/" || dir == "." {
+			return false
+		}
+	}
+}
+
+func (app *application) conditionProgram(condition *model.TriggerCondition) (*scriptProgram, error) {
+	return app.compileScript(
+		condition.Expand.Trigger.OrganizationId,
+		condition.Id,
+		condition.Updated,
+		fmt.Sprintf("%s#%s", condition.Expand.Trigger.Name, condition.Name),
+		script.Code(condition.Language, condition.Code, condition.CompiledCode),
+	)
+}
+
+func (app *application) triggerProgram(trigger *model.Trigger) (*scriptProgram, error) {
+	return app.compileScript(
+		trigger.OrganizationId,
+		trigger.Id,
+		trigger.Updated,
+		trigger.Name,
+		script.Code(trigger.Language, trigger.Code, trigger.CompiledCode),
+	)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/evntboard/app/backend/internal/matcher"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/pattern"
+	"github.com/nats-io/nats.go"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// a test answer on a request, the whole run (every condition and trigger) must
+// end far below the api request timeout
+const testMaxExecutionTime = 20 * time.Second
+
+var errTestTimeout = fmt.Errorf("%w : the test took more than %s", ErrExecutionTimeout, testMaxExecutionTime)
+
+// transcript capture what a test run would have done instead of doing it,
+// async calls record from their own goroutine
+type transcript struct {
+	mu    sync.Mutex
+	logs  []any
+	calls []model.TriggerTestCall
+}
+
+func (t *transcript) log(data ...any) {
+	var entry any = data
+	if len(data) == 1 {
+		entry = data[0]
+	}
+
+	// keep only what can be sent back as json
+	msgJson, err := json.Marshal(entry)
+	if err != nil {
+		entry = fmt.Sprint(entry)
+	} else {
+		entry = json.RawMessage(msgJson)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, entry)
+}
+
+func (t *transcript) call(callType string, module string, method string, params any) {
+	if _, err := json.Marshal(params); err != nil {
+		params = fmt.Sprint(params)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, model.TriggerTestCall{
+		Type:   callType,
+		Module: module,
+		Method: method,
+		Params: params,
+	})
+}
+
+func (app *application) onTriggerTestMessage(msg *nats.Msg) {
+	var request model.TriggerTestRequest
+	var response model.TriggerTestResponse
+
+	if err := json.Unmarshal(msg.Data, &request); err != nil {
+		response.Error = err.Error()
+	} else {
+		response = app.runTriggerTest(&request)
+	}
+
+	msgJson, err := json.Marshal(response)
+	if err != nil {
+		app.logger.Error("error marshal trigger test response", "error", err)
+		return
+	}
+
+	_ = msg.Respond(msgJson)
+}
+
+func (app *application) runTriggerTest(request *model.TriggerTestRequest) model.TriggerTestResponse {
+	response := model.TriggerTestResponse{
+		Results: make([]model.TriggerTestResult, 0),
+	}
+
+	deadline := time.Now().Add(testMaxExecutionTime)
+
+	conditions, err := app.pb.GetConditionsForOrganizationAndTrigger(request.OrganizationId, request.TriggerId)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	for _, condition := range conditions {
+		if request.ConditionId != "" && condition.Id != request.ConditionId {
+			continue
+		}
+
+		p, err := pattern.Parse(condition.Name)
+		if err != nil {
+			response.Results = append(response.Results, model.TriggerTestResult{
+				ConditionId:   condition.Id,
+				ConditionName: condition.Name,
+				Error:         err.Error(),
+			})
+			continue
+		}
+
+		// without an event name every exact condition is tried with its own name
+		event := &model.EventReceived{Event: request.Event}
+		if event.Name == "" {
+			if !p.IsExact() {
+				if request.ConditionId != "" {
+					response.Results = append(response.Results, model.TriggerTestResult{
+						ConditionId:   condition.Id,
+						ConditionName: condition.Name,
+						Error:         "an event name is needed to test a pattern condition",
+					})
+				}
+				continue
+			}
+			event.Name = condition.Name
+		} else if !p.Match(event.Name) && request.ConditionId == "" {
+			continue
+		}
+
+		response.Results = append(response.Results, app.runConditionTest(event, condition, deadline))
+	}
+
+	return response
+}
+
+func (app *application) runConditionTest(event *model.EventReceived, condition *model.TriggerCondition, deadline time.Time) (result model.TriggerTestResult) {
+	result.ConditionId = condition.Id
+	result.ConditionName = condition.Name
+
+	if !time.Now().Before(deadline) {
+		result.Error = errTestTimeout.Error()
+		return result
+	}
+
+	app.logger.Debug(
+		"test trigger",
+		slog.String("organization", event.OrganizationId),
+		slog.Group("trigger",
+			slog.String("id", condition.Expand.Trigger.Id),
+			slog.String("name", condition.Expand.Trigger.Name),
+		),
+		slog.Group("condition",
+			slog.String("id", condition.Id),
+			slog.String("name", condition.Name),
+		),
+	)
+
+	m, err := matcher.Parse(condition.Matcher)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if m != nil && !m.Match(eventMatchData(event)) {
+		return result
+	}
+
+	vmContext, err := NewVMContext(app, "", event, condition)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	capture := &transcript{}
+	vmContext.transcript = capture
+
+	// the phases stop at the deadline of the whole test, whatever their own
+	// max execution time
+	stop := time.AfterFunc(time.Until(deadline), func() {
+		vmContext.cancel(errTestTimeout)
+	})
+	defer stop.Stop()
+
+	defer func() {
+		capture.mu.Lock()
+		defer capture.mu.Unlock()
+		result.Logs = capture.logs
+		result.Calls = capture.calls
+	}()
+
+	value, err := vmContext.runWithTimeout(vmContext.maxExecutionTime(condition.MaxExecutionTime), func() (goja.Value, error) {
+		return vmContext.runConditionCode(condition)
+	})
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Result = value.ToBoolean()
+	if !result.Result {
+		return result
+	}
+
+	vmContext.installTriggerGlobals()
+
+	result.Executed = true
+
+	_, err = vmContext.runWithTimeout(vmContext.maxExecutionTime(condition.Expand.Trigger.MaxExecutionTime), func() (goja.Value, error) {
+		program, err := app.triggerProgram(&condition.Expand.Trigger)
+		if err != nil {
+			return nil, err
+		}
+
+		return vmContext.runProgram(program)
+	})
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -8,15 +9,35 @@ import (
 	"github.com/dop251/goja"
 	"github.com/evntboard/app/backend/internal/model"
 	"log"
+	"sync"
 	"time"
 )
 
+var ErrExecutionTimeout = errors.New("execution timeout")
+
+type CancelError struct {
+	UserId string
+}
+
+func (e *CancelError) Error() string {
+	return "process cancelled"
+}
+
 type VMContext struct {
 	app             *application
 	vm              *goja.Runtime
+	ctx             context.Context
+	cancel          context.CancelCauseFunc
 	processRecordId string
 	event           *model.EventReceived
 	trigger         *model.TriggerCondition
+	loop            *eventLoop
+	httpHostsOnce   sync.Once
+	httpHosts       []string
+	httpHostsErr    error
+	transcript      *transcript
+	sleepWarned     bool
+	globalsLoaded   map[string]bool
 }
 
 func NewVMContext(
@@ -25,16 +46,23 @@ func NewVMContext(
 	event *model.EventReceived,
 	trigger *model.TriggerCondition,
 ) (*VMContext, error) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
 	vmContext := &VMContext{
 		app:             app,
 		vm:              goja.New(),
+		ctx:             ctx,
+		cancel:          cancel,
 		processRecordId: processRecordId,
 		event:           event,
 		trigger:         trigger,
+		loop:            newEventLoop(),
+		globalsLoaded:   make(map[string]bool),
 	}
 
 	var payloadRaw any
 	if err := json.Unmarshal(event.Payload, &payloadRaw); err != nil {
+		cancel(err)
 		return nil, err
 	}
 	eventObj := vmContext.vm.NewObject()
@@ -44,13 +72,133 @@ func NewVMContext(
 	_ = eventObj.Set("emitted_at", event.EmittedAt)
 	_ = eventObj.Set("emitter_code", event.EmitterCode)
 	_ = eventObj.Set("emitter_name", event.EmitterName)
+	_ = eventObj.Set("emitted_by", event.EmittedBy)
+	_ = eventObj.Set("schema_errors", event.SchemaErrors)
 	_ = vmContext.vm.Set("event", eventObj)
 
+	vmContext.installEventLoop()
+
 	return vmContext, nil
 }
 
+// installTriggerGlobals expose the apis only available to the trigger code
+func (vmContext *VMContext) installTriggerGlobals() {
+	moduleObj := vmContext.vm.NewObject()
+
+	_ = moduleObj.Set("request", vmContext.vmModuleNameRequestCall)
+	_ = moduleObj.Set("notify", vmContext.vmModuleNameNotifyCall)
+	_ = vmContext.vm.Set("module", moduleObj)
+
+	storageObj := vmContext.vm.NewObject()
+
+	_ = storageObj.Set("set", vmContext.vmStorageSet)
+	_ = storageObj.Set("get", vmContext.vmStorageGet)
+
+	_ = vmContext.vm.Set("storage", storageObj)
+
+	httpObj := vmContext.vm.NewObject()
+
+	_ = httpObj.Set("request", vmContext.vmHttpRequest)
+
+	_ = vmContext.vm.Set("http", httpObj)
+	_ = vmContext.vm.Set("fetch", vmContext.vmFetch)
+
+	_ = vmContext.vm.Set("log", vmContext.vmLog)
+	_ = vmContext.vm.Set("sleep", vmContext.vmSleep)
+}
+
+// dryRun is true for a replay where module calls, http calls and storage writes are stubbed
+func (vmContext *VMContext) dryRun() bool {
+	return vmContext.event.Replay != nil && vmContext.event.Replay.DryRun
+}
+
+// dryRunRequest record a call the process would have made, nothing is sent
+func (vmContext *VMContext) dryRunRequest(moduleId string, method string, params any, isNotification bool) error {
+	processRequestRecordId, err := vmContext.app.pb.CreateProcessRequest(
+		vmContext.processRecordId,
+		moduleId,
+		method,
+		params,
+		isNotification,
+	)
+	if err != nil {
+		return err
+	}
+
+	result, _ := json.Marshal(map[string]any{"dry_run": true})
+
+	return vmContext.app.pb.UpdateSuccessProcessRequest(processRequestRecordId, result)
+}
+
+func (vmContext *VMContext) dryRunModuleRequest(moduleName string, moduleMethod string, params any, isNotification bool) error {
+	// the module doesn't need to be connected for a dry run
+	moduleId := ""
+	module, err := vmContext.app.pb.GetModuleWithSessionByOrganizationIdAndNameOrCode(vmContext.trigger.Expand.Trigger.OrganizationId, moduleName)
+	if err == nil {
+		if err := validateModuleCall(module, moduleMethod, params); err != nil {
+			return err
+		}
+		moduleId = module.Id
+	}
+
+	return vmContext.dryRunRequest(moduleId, moduleMethod, params, isNotification)
+}
+
+func (vmContext *VMContext) Cancel(userId string) {
+	vmContext.cancel(&CancelError{UserId: userId})
+}
+
+func (vmContext *VMContext) runWithTimeout(timeout time.Duration, run func() (goja.Value, error)) (goja.Value, error) {
+	parentCtx := vmContext.ctx
+	ctx, cancel := context.WithTimeoutCause(parentCtx, timeout, fmt.Errorf("%w after %s", ErrExecutionTimeout, timeout))
+	defer cancel()
+
+	// blocking calls (sleep, module.request) stop waiting when ctx is done
+	vmContext.ctx = ctx
+	defer func() {
+		vmContext.ctx = parentCtx
+	}()
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		vmContext.vm.Interrupt(context.Cause(ctx))
+		close(interrupted)
+	})
+
+	value, err := run()
+
+	if !stop() {
+		<-interrupted
+	}
+	vmContext.vm.ClearInterrupt()
+
+	// a blocking call aborted by ctx surface its own error, report the real cause
+	if err != nil && ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	return value, err
+}
+
+func (vmContext *VMContext) maxExecutionTime(ms int) time.Duration {
+	if ms <= 0 {
+		return vmContext.app.config.maxExecutionTime
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// vmSleep is deprecated, it block the whole script : setTimeout let the
+// other callbacks run meanwhile. Kept for the scripts still using it
 func (vmContext *VMContext) vmSleep(ms int) {
-	time.Sleep(time.Duration(ms) * time.Millisecond)
+	if !vmContext.sleepWarned {
+		vmContext.sleepWarned = true
+		vmContext.vmLog("sleep is deprecated, use setTimeout instead")
+	}
+
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+	case <-vmContext.ctx.Done():
+	}
 }
 
 func (vmContext *VMContext) vmLog(data ...any) {
@@ -58,6 +206,11 @@ func (vmContext *VMContext) vmLog(data ...any) {
 		return
 	}
 
+	if vmContext.transcript != nil {
+		vmContext.transcript.log(data...)
+		return
+	}
+
 	go func() {
 		payload := ""
 		if len(data) == 1 {
@@ -79,10 +232,52 @@ func (vmContext *VMContext) vmLog(data ...any) {
 	}()
 }
 
-func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMethod string, params any) any {
+func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMethod string, params any, options map[string]any) any {
+	// module.request(name, method, params, { async: true }) return a Promise
+	if async, ok := options["async"].(bool); ok && async {
+		promise, resolve, reject := vmContext.vm.NewPromise()
+
+		vmContext.async(func(ctx context.Context) eventLoopJob {
+			result, err := vmContext.moduleRequest(ctx, moduleName, moduleMethod, params)
+
+			return func() error {
+				if err != nil {
+					reject(vmContext.vm.NewGoError(err))
+				} else {
+					resolve(result)
+				}
+				return nil
+			}
+		})
+
+		return promise
+	}
+
+	result, err := vmContext.moduleRequest(vmContext.ctx, moduleName, moduleMethod, params)
+	if err != nil {
+		panic(vmContext.vm.NewGoError(err))
+	}
+
+	return result
+}
+
+func (vmContext *VMContext) moduleRequest(ctx context.Context, moduleName string, moduleMethod string, params any) (any, error) {
+	if vmContext.transcript != nil {
+		vmContext.transcript.call("module.request", moduleName, moduleMethod, params)
+		return nil, nil
+	}
+
+	if vmContext.dryRun() {
+		return nil, vmContext.dryRunModuleRequest(moduleName, moduleMethod, params, false)
+	}
+
 	module, err := vmContext.app.pb.GetModuleWithSessionByOrganizationIdAndNameOrCode(vmContext.trigger.Expand.Trigger.OrganizationId, moduleName)
 	if err != nil {
-		panic(vmContext.vm.NewGoError(fmt.Errorf("there is no %s connected", moduleName)))
+		return nil, fmt.Errorf("there is no %s connected", moduleName)
+	}
+
+	if err := validateModuleCall(module, moduleMethod, params); err != nil {
+		return nil, err
 	}
 
 	processRequestRecordId, err := vmContext.app.pb.CreateProcessRequest(
@@ -93,7 +288,7 @@ func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMet
 		false,
 	)
 	if err != nil {
-		panic(vmContext.vm.NewGoError(fmt.Errorf("Error create process request module request :%s\n", err.Error())))
+		return nil, fmt.Errorf("Error create process request module request :%s\n", err.Error())
 	}
 
 	msgJson, err := json.Marshal(map[string]any{
@@ -105,13 +300,28 @@ func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMet
 		},
 	})
 	if err != nil {
-		panic(vmContext.vm.NewGoError(fmt.Errorf("error encoding json : %s", err.Error())))
+		return nil, fmt.Errorf("error encoding json : %s", err.Error())
+	}
+
+	// the request is kept, errored, so the process doesn't show a call waiting
+	// forever
+	session, err := vmContext.app.instances.pick(module)
+	if err != nil {
+		err = fmt.Errorf("there is no %s connected", moduleName)
+		msg, _ := json.Marshal(err.Error())
+		if updateErr := vmContext.app.pb.UpdateErrorProcessRequest(processRequestRecordId, msg); updateErr != nil {
+			fmt.Printf("Error module request %s\n", updateErr.Error())
+		}
+		return nil, err
 	}
 
-	callResult, callError := vmContext.app.realtime.Request(
-		vmContext.app.realtime.GetChannelForModule(module.SessionId),
+	requestCtx, cancel := context.WithTimeout(ctx, time.Minute*15)
+	defer cancel()
+
+	callResult, callError := vmContext.app.realtime.RequestWithContext(
+		requestCtx,
+		vmContext.app.realtime.GetChannelForModule(session),
 		msgJson,
-		time.Minute*15,
 	)
 
 	if callError != nil {
@@ -126,7 +336,7 @@ func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMet
 			fmt.Printf("Error module request %s\n", err.Error())
 		}
 
-		panic(vmContext.vm.NewGoError(fmt.Errorf("Error module request : %s", callError)))
+		return nil, fmt.Errorf("Error module request : %s", callError)
 	}
 
 	// callResult can be an error :)
@@ -147,7 +357,7 @@ func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMet
 			fmt.Printf("Error module request %s\n", err.Error())
 		}
 
-		panic(vmContext.vm.NewGoError(fmt.Errorf("Error module request : %s", msg)))
+		return nil, fmt.Errorf("Error module request : %s", msg)
 	}
 
 	if _, ok := rawResult["success"]; ok {
@@ -156,13 +366,25 @@ func (vmContext *VMContext) vmModuleNameRequestCall(moduleName string, moduleMet
 			log.Println("Erreur de codage JSON de la requête:", err)
 		}
 		err = vmContext.app.pb.UpdateSuccessProcessRequest(processRequestRecordId, msg)
-		return rawResult["success"]
+		return rawResult["success"], nil
 	}
 
-	panic(vmContext.vm.NewGoError(fmt.Errorf("invalid result")))
+	return nil, fmt.Errorf("invalid result")
 }
 
-func (vmContext *VMContext) vmModuleNameNotifyCall(moduleName string, moduleMethod string, params any) {
+// vmModuleNameNotifyCall send to one instance of the module, or to every
+// instance with module.notify(name, method, params, { broadcast: true })
+func (vmContext *VMContext) vmModuleNameNotifyCall(moduleName string, moduleMethod string, params any, options map[string]any) {
+	if vmContext.transcript != nil {
+		vmContext.transcript.call("module.notify", moduleName, moduleMethod, params)
+		return
+	}
+
+	if vmContext.dryRun() {
+		_ = vmContext.dryRunModuleRequest(moduleName, moduleMethod, params, true)
+		return
+	}
+
 	module, err := vmContext.app.pb.GetModuleWithSessionByOrganizationIdAndNameOrCode(vmContext.trigger.Expand.Trigger.OrganizationId, moduleName)
 	if err != nil {
 		return
@@ -179,6 +401,13 @@ func (vmContext *VMContext) vmModuleNameNotifyCall(moduleName string, moduleMeth
 		return
 	}
 
+	// a notification has nobody to answer, the refusal is kept on the request
+	if err := validateModuleCall(module, moduleMethod, params); err != nil {
+		msg, _ := json.Marshal(err.Error())
+		_ = vmContext.app.pb.UpdateErrorProcessRequest(processRequestRecordId, msg)
+		return
+	}
+
 	msgJson, err := json.Marshal(map[string]any{
 		"type":   "module",
 		"action": "notify",
@@ -191,10 +420,23 @@ func (vmContext *VMContext) vmModuleNameNotifyCall(moduleName string, moduleMeth
 		return
 	}
 
-	callError := vmContext.app.realtime.Publish(
-		vmContext.app.realtime.GetChannelForModule(module.SessionId),
-		msgJson,
-	)
+	var sessions []string
+	if broadcast, ok := options["broadcast"].(bool); ok && broadcast {
+		sessions = moduleSessions(module)
+	} else if session, err := vmContext.app.instances.pick(module); err == nil {
+		sessions = []string{session}
+	}
+
+	var callError error
+	if len(sessions) == 0 {
+		callError = fmt.Errorf("there is no %s connected", moduleName)
+	}
+
+	for _, session := range sessions {
+		if err := vmContext.app.realtime.Publish(vmContext.app.realtime.GetChannelForModule(session), msgJson); err != nil {
+			callError = err
+		}
+	}
 
 	if callError != nil {
 		msg, err := json.Marshal(callError)
@@ -217,6 +459,15 @@ func (vmContext *VMContext) vmStorageSet(key string, value any) any {
 		panic(vmContext.vm.NewGoError(fmt.Errorf("storage get key cannot be less than 3 chars")))
 	}
 
+	if vmContext.transcript != nil {
+		vmContext.transcript.call("storage.set", "", key, value)
+		return value
+	}
+
+	if vmContext.dryRun() {
+		return value
+	}
+
 	record, err := vmContext.app.pb.SetStorageByKey(vmContext.trigger.Expand.Trigger.OrganizationId, key, value)
 
 	if err != nil {
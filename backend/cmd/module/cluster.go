@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/nats-io/nats.go"
+	"github.com/sourcegraph/jsonrpc2"
+	"log/slog"
+	"time"
+)
+
+// sessionControl is sent to the replica that hold a session, on the control
+// channel of the session
+type sessionControl struct {
+	Action string `json:"action"`
+	Token  string `json:"token,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// sessionHandover is what a replica give to the one where the module resumed
+type sessionHandover struct {
+	Module  *model.Module      `json:"module"`
+	Token   *model.ModuleToken `json:"token"`
+	Pending []handoverMessage  `json:"pending"`
+}
+
+type handoverMessage struct {
+	Data  []byte `json:"data"`
+	Reply string `json:"reply"`
+}
+
+func (link *moduleLink) addSubscription(sub *nats.Subscription) {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+	link.subscriptions = append(link.subscriptions, sub)
+}
+
+func (link *moduleLink) unsubscribe() {
+	link.mu.Lock()
+	subscriptions := link.subscriptions
+	link.subscriptions = nil
+	link.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		_ = sub.Unsubscribe()
+	}
+}
+
+// subscribeSession listen the channel of the session in a queue group : while
+// a session move between replicas both listen and only one get each message
+func (app *application) subscribeSession(link *moduleLink) error {
+	channel := app.realtime.GetChannelForModule(link.session.Module.SessionId)
+
+	sub, err := app.realtime.QueueSubscribe(channel, channel, func(msg *nats.Msg) {
+		app.onModuleMessage(link, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	link.addSubscription(sub)
+	return nil
+}
+
+func (app *application) subscribeSessionControl(link *moduleLink) error {
+	sub, err := app.realtime.Subscribe(app.realtime.GetChannelForModuleControl(link.session.Module.SessionId), func(msg *nats.Msg) {
+		app.onSessionControl(link, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	link.addSubscription(sub)
+	return nil
+}
+
+func (app *application) onSessionControl(link *moduleLink, msg *nats.Msg) {
+	var control sessionControl
+
+	if err := json.Unmarshal(msg.Data, &control); err != nil {
+		app.replySessionControl(msg, map[string]any{"error": "not valid message"})
+		return
+	}
+
+	switch control.Action {
+	case "ping":
+		app.replySessionControl(msg, map[string]any{"success": true})
+	case "close":
+		closed := app.closeSession(link, true, control.Reason)
+		app.replySessionControl(msg, map[string]any{"success": closed})
+	case "handover":
+		conn, pending, ok := link.handover(control.Token)
+		if !ok {
+			app.replySessionControl(msg, map[string]any{"error": "invalid resume token"})
+			return
+		}
+
+		// the session is not closed, it only leave this replica
+		app.sessionsMu.Lock()
+		delete(app.links, link.session.Module.SessionId)
+		for current, session := range app.sessions {
+			if session == link.session {
+				delete(app.sessions, current)
+			}
+		}
+		app.sessionsMu.Unlock()
+
+		link.unsubscribe()
+
+		if conn != nil {
+			_ = conn.Close()
+		}
+
+		handover := sessionHandover{
+			Module:  link.session.Module,
+			Token:   link.session.Token,
+			Pending: make([]handoverMessage, 0, len(pending)),
+		}
+		for _, pendingMsg := range pending {
+			handover.Pending = append(handover.Pending, handoverMessage{
+				Data:  pendingMsg.Data,
+				Reply: pendingMsg.Reply,
+			})
+		}
+
+		app.replySessionControl(msg, map[string]any{"success": handover})
+	default:
+		app.replySessionControl(msg, map[string]any{"error": "unknown action"})
+	}
+}
+
+func (app *application) replySessionControl(msg *nats.Msg, reply map[string]any) {
+	if msg.Reply == "" {
+		return
+	}
+
+	msgJson, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+
+	_ = app.realtime.Publish(msg.Reply, msgJson)
+}
+
+// forwardModuleMessage send again a message received after the session moved,
+// the replica that hold it now get it
+func (app *application) forwardModuleMessage(msg *nats.Msg) {
+	_ = app.realtime.PublishMsg(&nats.Msg{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    msg.Data,
+	})
+}
+
+// resumeRemoteSession take a session held by another replica, the module
+// reached this one after its websocket dropped
+func (app *application) resumeRemoteSession(client *jsonrpc2.Conn, token string) (*model.ModuleSession, bool) {
+	sessionId := sessionFromResumeToken(token)
+	if sessionId == "" {
+		return nil, false
+	}
+
+	link := &moduleLink{
+		session: &model.ModuleSession{
+			Module: &model.Module{SessionId: sessionId},
+		},
+		resumable: true,
+		resuming:  true,
+	}
+
+	// listen before the handover so no message is lost, they wait without
+	// websocket until the replica holding the session accept the token
+	if err := app.subscribeSession(link); err != nil {
+		return nil, false
+	}
+
+	msgJson, _ := json.Marshal(sessionControl{
+		Action: "handover",
+		Token:  token,
+	})
+
+	reply, err := app.realtime.Request(app.realtime.GetChannelForModuleControl(sessionId), msgJson, 3*time.Second)
+	if err != nil {
+		app.abandonRemoteSession(link)
+		return nil, false
+	}
+
+	var result struct {
+		Success *sessionHandover `json:"success"`
+	}
+	if err := json.Unmarshal(reply.Data, &result); err != nil || result.Success == nil || result.Success.Module == nil {
+		app.abandonRemoteSession(link)
+		return nil, false
+	}
+
+	link.session.Module = result.Success.Module
+	link.session.Token = result.Success.Token
+
+	_, held, _ := link.attach(client, newResumeToken(sessionId))
+
+	if err := app.subscribeSessionControl(link); err != nil {
+		app.logger.Error("can't listen module session control", slog.String("error", err.Error()))
+	}
+
+	app.sessionsMu.Lock()
+	app.sessions[client] = link.session
+	app.links[sessionId] = link
+	app.sessionsMu.Unlock()
+
+	if err := app.pb.ClaimModuleSession(sessionId, app.config.nodeId); err != nil {
+		app.logger.Error("can't claim module session", slog.String("error", err.Error()))
+	}
+
+	app.logger.Info(
+		"module session resumed from another replica",
+		slog.Group("module",
+			slog.String("organization", link.session.Module.OrganizationId),
+			slog.String("code", link.session.Module.Code),
+			slog.String("name", link.session.Module.Name),
+		),
+		slog.Int("pending", len(result.Success.Pending)),
+	)
+
+	channel := app.realtime.GetChannelForModule(sessionId)
+	go func() {
+		for _, pending := range result.Success.Pending {
+			app.onModuleMessage(link, &nats.Msg{
+				Subject: channel,
+				Reply:   pending.Reply,
+				Data:    pending.Data,
+			})
+		}
+		for _, msg := range held {
+			app.onModuleMessage(link, msg)
+		}
+	}()
+
+	return link.session, true
+}
+
+// abandonRemoteSession stop listening a session this replica didn't get, what
+// it received meanwhile is sent again for the replica holding it
+func (app *application) abandonRemoteSession(link *moduleLink) {
+	pending := link.abandon()
+	link.unsubscribe()
+
+	for _, msg := range pending {
+		app.forwardModuleMessage(msg)
+	}
+}
+
+// FreeModuleInstance make room for a module that registered again instead of
+// resuming : a detached instance is closed by its replica, an instance whose
+// replica stopped without cleaning is removed
+func (app *application) FreeModuleInstance(module *model.Module) bool {
+	msgJson, _ := json.Marshal(sessionControl{
+		Action: "close",
+		Reason: "replaced",
+	})
+
+	for _, instance := range module.Expand.Instances {
+		reply, err := app.realtime.Request(app.realtime.GetChannelForModuleControl(instance.Session), msgJson, 3*time.Second)
+
+		if errors.Is(err, nats.ErrNoResponders) {
+			if app.removeStaleInstance(module, instance.Session) {
+				return true
+			}
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		var result struct {
+			Success bool `json:"success"`
+		}
+		if err := json.Unmarshal(reply.Data, &result); err == nil && result.Success {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeStaleInstance remove an instance no replica hold anymore
+func (app *application) removeStaleInstance(module *model.Module, session string) bool {
+	if err := app.pb.RemoveModuleSession(module, session); err != nil {
+		app.logger.Error("can't remove module session", slog.String("error", err.Error()))
+		return false
+	}
+
+	stale := *module
+	stale.SessionId = session
+	app.emitModuleEvent(moduleDisconnectedEvent, &model.ModuleSession{Module: &stale}, "reclaimed")
+	return true
+}
+
+// sweepSessions probe the instances of every replica, the ones of a replica
+// that never came back (a new hostname, a scale down) or from before the nodes
+// have no responder and are removed
+func (app *application) sweepSessions() {
+	if app.config.sessionSweepInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.sessionSweepInterval)
+	defer ticker.Stop()
+
+	msgJson, _ := json.Marshal(sessionControl{
+		Action: "ping",
+	})
+
+	for range ticker.C {
+		instances, err := app.pb.GetModuleInstancesCreatedBefore(time.Now().Add(-app.config.sessionSweepInterval))
+		if err != nil {
+			app.logger.Error("can't list module sessions", slog.String("error", err.Error()))
+			continue
+		}
+
+		for _, instance := range instances {
+			if instance.Expand.Module == nil {
+				continue
+			}
+
+			if app.instanceGone(instance.Session, msgJson) {
+				app.removeStaleInstance(instance.Expand.Module, instance.Session)
+			}
+		}
+	}
+}
+
+// instanceGone ask twice, a session moving between replicas has no control
+// subscription for a short moment
+func (app *application) instanceGone(session string, msgJson []byte) bool {
+	for try := 0; try < 2; try++ {
+		if try > 0 {
+			time.Sleep(time.Second)
+		}
+
+		_, err := app.realtime.Request(app.realtime.GetChannelForModuleControl(session), msgJson, 3*time.Second)
+		if !errors.Is(err, nats.ErrNoResponders) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reclaimNodeSessions remove the sessions this replica held before it
+// restarted, the sessions of the other replicas stay
+func (app *application) reclaimNodeSessions() error {
+	instances, err := app.pb.ResetModuleSessionsByNode(app.config.nodeId)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if instance.Expand.Module == nil {
+			continue
+		}
+
+		module := *instance.Expand.Module
+		module.SessionId = instance.Session
+		app.emitModuleEvent(moduleDisconnectedEvent, &model.ModuleSession{Module: &module}, "reclaimed")
+	}
+
+	return nil
+}
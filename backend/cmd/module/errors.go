@@ -51,3 +51,7 @@ func (app *application) methodNotAllowed(w http.ResponseWriter, r *http.Request)
 func (app *application) badRequest(w http.ResponseWriter, r *http.Request, err error) {
 	app.errorMessage(w, r, http.StatusBadRequest, err.Error(), nil)
 }
+
+func (app *application) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorMessage(w, r, http.StatusUnauthorized, err.Error(), nil)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/moduletoken"
 	"github.com/evntboard/app/backend/internal/response"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/sourcegraph/jsonrpc2"
@@ -20,6 +22,8 @@ func (app *application) rpc(w http.ResponseWriter, r *http.Request) {
 
 	client := jsonrpc2.NewConn(r.Context(), ws.NewObjectStream(conn), jsonrpc2.AsyncHandler(newRPCMethodHandler(app)))
 
+	go app.heartbeat(client)
+
 	time.AfterFunc(10*time.Second, func() {
 		app.logger.Debug("rpc check session")
 		currentClientState := app.GetSession(client)
@@ -67,7 +71,7 @@ func (m *InputModulePostRequestData) Validate() error {
 
 	err = validation.ValidateStruct(
 		&m.Event,
-		validation.Field(&m.Event.Name, validation.Required, validation.Length(4, 100)),
+		validation.Field(&m.Event.Name, validation.Required, validation.Length(4, 100), validation.Match(eventNameRX)),
 		validation.Field(&m.Event.Payload, validation.Required),
 	)
 
@@ -91,7 +95,7 @@ func (app *application) modulePostEvent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	module, err := app.pb.GetModuleByCodeNameToken(
+	module, token, err := app.pb.GetModuleByCodeNameToken(
 		postData.Module.Code,
 		postData.Module.Name,
 		postData.Module.Token,
@@ -102,16 +106,26 @@ func (app *application) modulePostEvent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	created, err := app.pb.CreateEvent(
-		model.Event{
-			OrganizationId: module.OrganizationId,
-			Name:           postData.Event.Name,
-			Payload:        postData.Event.Payload,
-			EmitterCode:    module.Code,
-			EmitterName:    module.Name,
-			EmittedAt:      time.Now().Format(time.RFC3339Nano),
-		},
-	)
+	if !moduletoken.AllowMethod(token.Methods, moduletoken.MethodEventNew) || !moduletoken.AllowEvent(token.Events, postData.Event.Name) {
+		app.unauthorized(w, r, errors.New("token not allowed to emit "+postData.Event.Name))
+		return
+	}
+
+	event := model.Event{
+		OrganizationId: module.OrganizationId,
+		Name:           postData.Event.Name,
+		Payload:        postData.Event.Payload,
+		EmitterCode:    module.Code,
+		EmitterName:    module.Name,
+		EmittedAt:      time.Now().Format(time.RFC3339Nano),
+	}
+
+	if err := app.validateEventPayload(&event); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	created, err := app.pb.CreateEvent(event)
 
 	if err != nil {
 		app.badRequest(w, r, err)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"github.com/evntboard/app/backend/internal/moduletoken"
 	"github.com/sourcegraph/jsonrpc2"
 	"log/slog"
 )
@@ -17,9 +18,17 @@ func newRPCMethodHandler(app *application) *rpcMethodHandler {
 }
 
 func (h *rpcMethodHandler) Handle(ctx context.Context, c *jsonrpc2.Conn, r *jsonrpc2.Request) {
+	if h.forbidden(ctx, c, r) {
+		return
+	}
+
 	switch r.Method {
 	case "session.register":
 		h.sessionRegister(ctx, c, r)
+	case "session.resume":
+		h.sessionResume(ctx, c, r)
+	case "session.ping":
+		h.sessionPing(ctx, c, r)
 	case "event.new":
 		h.eventNew(ctx, c, r)
 	case "storage.get":
@@ -31,6 +40,28 @@ func (h *rpcMethodHandler) Handle(ctx context.Context, c *jsonrpc2.Conn, r *json
 	}
 }
 
+// forbidden refuse the methods out of the scope of the token the session
+// registered with
+func (h *rpcMethodHandler) forbidden(ctx context.Context, c *jsonrpc2.Conn, r *jsonrpc2.Request) bool {
+	session := h.app.GetSession(c)
+
+	if session == nil || session.Token == nil || moduletoken.AllowMethod(session.Token.Methods, r.Method) {
+		return false
+	}
+
+	if !r.Notif {
+		_ = c.ReplyWithError(
+			ctx,
+			r.ID,
+			&jsonrpc2.Error{
+				Code:    jsonrpc2.CodeInvalidRequest,
+				Message: "Token not allowed to call " + r.Method,
+			},
+		)
+	}
+	return true
+}
+
 func (h *rpcMethodHandler) defaultCase(ctx context.Context, c *jsonrpc2.Conn, r *jsonrpc2.Request) {
 	session := h.app.GetSession(c)
 
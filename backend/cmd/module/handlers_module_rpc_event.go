@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/moduletoken"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/sourcegraph/jsonrpc2"
+	"regexp"
 	"time"
 )
 
+var eventNameRX = regexp.MustCompile(`^[a-z_-]+(\.[a-z_-]+)*$`)
+
 type InputNewEventData struct {
 	Name    string          `json:"name"`
 	Payload json.RawMessage `json:"payload"`
@@ -17,7 +21,7 @@ type InputNewEventData struct {
 func (a InputNewEventData) Validate() error {
 	return validation.ValidateStruct(
 		&a,
-		validation.Field(&a.Name, validation.Required, validation.Length(3, 100)),
+		validation.Field(&a.Name, validation.Required, validation.Length(3, 100), validation.Match(eventNameRX)),
 		validation.Field(&a.Payload, validation.Required),
 	)
 }
@@ -69,16 +73,44 @@ func (h *rpcMethodHandler) eventNew(ctx context.Context, c *jsonrpc2.Conn, r *js
 		return
 	}
 
-	created, err := h.app.pb.CreateEvent(
-		model.Event{
-			OrganizationId: session.Module.OrganizationId,
-			Name:           data.Name,
-			Payload:        data.Payload,
-			EmitterCode:    session.Module.Code,
-			EmitterName:    session.Module.Name,
-			EmittedAt:      time.Now().Format(time.RFC3339Nano),
-		},
-	)
+	if session.Token != nil && !moduletoken.AllowEvent(session.Token.Events, data.Name) {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInvalidRequest,
+					Message: "Token not allowed to emit " + data.Name,
+				},
+			)
+		}
+		return
+	}
+
+	event := model.Event{
+		OrganizationId: session.Module.OrganizationId,
+		Name:           data.Name,
+		Payload:        data.Payload,
+		EmitterCode:    session.Module.Code,
+		EmitterName:    session.Module.Name,
+		EmittedAt:      time.Now().Format(time.RFC3339Nano),
+	}
+
+	if err := h.app.validateEventPayload(&event); err != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInvalidParams,
+					Message: err.Error(),
+				},
+			)
+		}
+		return
+	}
+
+	created, err := h.app.pb.CreateEvent(event)
 
 	if err != nil {
 		if !r.Notif {
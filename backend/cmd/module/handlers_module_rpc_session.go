@@ -3,14 +3,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
 type InputSessionRegisterData struct {
-	Code  string `json:"code"`
-	Name  string `json:"name"`
-	Token string `json:"token"`
+	Code      string                `json:"code"`
+	Name      string                `json:"name"`
+	Token     string                `json:"token"`
+	Manifest  *model.ModuleManifest `json:"manifest"`
+	Resumable bool                  `json:"resumable"`
 }
 
 func (a InputSessionRegisterData) Validate() error {
@@ -19,6 +23,9 @@ func (a InputSessionRegisterData) Validate() error {
 		validation.Field(&a.Code, validation.Required),
 		validation.Field(&a.Name, validation.Required),
 		validation.Field(&a.Token, validation.Required),
+		validation.Field(&a.Manifest, validation.By(func(value any) error {
+			return validateManifest(a.Manifest)
+		})),
 	)
 }
 
@@ -53,10 +60,25 @@ func (h *rpcMethodHandler) sessionRegister(ctx context.Context, c *jsonrpc2.Conn
 		return
 	}
 
+	// one session per websocket, a second one would orphan the first
+	if h.app.GetSession(c) != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInvalidRequest,
+					Message: "Session already registered",
+				},
+			)
+		}
+		return
+	}
+
 	// check if module exist
-	module, err := h.app.pb.GetModuleByCodeNameToken(data.Code, data.Name, data.Token)
+	module, token, err := h.app.pb.GetModuleByCodeNameToken(data.Code, data.Name, data.Token)
 
-	if err != nil || module == nil || module.SessionId != "" {
+	if err != nil || module == nil {
 		if !r.Notif {
 			_ = c.ReplyWithError(
 				ctx,
@@ -70,7 +92,55 @@ func (h *rpcMethodHandler) sessionRegister(ctx context.Context, c *jsonrpc2.Conn
 		_ = c.Close()
 		return
 	}
-	if err := h.app.AddSession(c, module); err != nil {
+
+	// max_sessions instances can run at once, a module that lost its resume
+	// token take the place of a detached or stale instance
+	if len(module.Expand.Instances) >= max(module.MaxSessions, 1) && !h.app.FreeModuleInstance(module) {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInternalError,
+					Message: "Module doesn't exist or is already connected",
+				},
+			)
+		}
+		_ = c.Close()
+		return
+	}
+
+	if err := h.app.pb.UpdateModuleManifest(module, data.Manifest); err != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInternalError,
+					Message: "Error saving module manifest",
+				},
+			)
+		}
+		_ = c.Close()
+		return
+	}
+
+	if err := h.app.AddSession(c, module, token, data.Resumable); err != nil {
+		// the websocket keep the session that was registered first
+		if errors.Is(err, errSessionRegistered) {
+			if !r.Notif {
+				_ = c.ReplyWithError(
+					ctx,
+					r.ID,
+					&jsonrpc2.Error{
+						Code:    jsonrpc2.CodeInvalidRequest,
+						Message: "Session already registered",
+					},
+				)
+			}
+			return
+		}
+
 		if !r.Notif {
 			_ = c.ReplyWithError(
 				ctx,
@@ -85,6 +155,114 @@ func (h *rpcMethodHandler) sessionRegister(ctx context.Context, c *jsonrpc2.Conn
 		return
 	}
 	if !r.Notif {
-		_ = c.Reply(ctx, r.ID, module.Expand.Params)
+		if !data.Resumable {
+			_ = c.Reply(ctx, r.ID, module.Expand.Params)
+			return
+		}
+
+		session := h.app.GetSession(c)
+		_ = c.Reply(ctx, r.ID, newSessionResumable(session))
+	}
+}
+
+// SessionResumable is the session.register reply of a resumable module, the
+// older modules only get the params
+type SessionResumable struct {
+	Session     string              `json:"session"`
+	ResumeToken string              `json:"resume_token"`
+	Params      []model.ModuleParam `json:"params"`
+}
+
+func newSessionResumable(session *model.ModuleSession) SessionResumable {
+	return SessionResumable{
+		Session:     session.Module.SessionId,
+		ResumeToken: session.ResumeToken,
+		Params:      session.Module.Expand.Params,
+	}
+}
+
+type InputSessionResumeData struct {
+	ResumeToken string `json:"resume_token"`
+}
+
+func (a InputSessionResumeData) Validate() error {
+	return validation.ValidateStruct(
+		&a,
+		validation.Field(&a.ResumeToken, validation.Required),
+	)
+}
+
+// sessionResume attach a new websocket to a session dropped less than the grace
+// period ago, the requests sent in between are replayed on it
+func (h *rpcMethodHandler) sessionResume(ctx context.Context, c *jsonrpc2.Conn, r *jsonrpc2.Request) {
+	var data InputSessionResumeData
+
+	if err := json.Unmarshal(*r.Params, &data); err != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInternalError,
+					Message: "Error when unmarshal params",
+				},
+			)
+		}
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInternalError,
+					Message: "Error on params format " + err.Error(),
+				},
+			)
+		}
+		return
+	}
+
+	if h.app.GetSession(c) != nil {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInvalidRequest,
+					Message: "Session already registered",
+				},
+			)
+		}
+		return
+	}
+
+	session, ok := h.app.ResumeSession(c, data.ResumeToken)
+
+	if !ok {
+		if !r.Notif {
+			_ = c.ReplyWithError(
+				ctx,
+				r.ID,
+				&jsonrpc2.Error{
+					Code:    jsonrpc2.CodeInternalError,
+					Message: "Session expired, register again",
+				},
+			)
+		}
+		return
+	}
+
+	if !r.Notif {
+		_ = c.Reply(ctx, r.ID, newSessionResumable(session))
+	}
+}
+
+// sessionPing let a module check that its websocket is still alive
+func (h *rpcMethodHandler) sessionPing(ctx context.Context, c *jsonrpc2.Conn, r *jsonrpc2.Request) {
+	if !r.Notif {
+		_ = c.Reply(ctx, r.ID, "pong")
 	}
 }
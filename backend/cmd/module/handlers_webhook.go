@@ -0,0 +1,345 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookEmitterCode            = "webhook"
+	webhookDefaultSignatureHeader = "X-Evntboard-Signature"
+	webhookMaxBodySize            = 1 << 20
+	webhookStripeTolerance        = 5 * time.Minute
+	webhookTwitchTolerance        = 10 * time.Minute
+)
+
+var (
+	webhookOrganizationRX = regexp.MustCompile(`^[a-z0-9]{15}$`)
+	webhookSlugRX         = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+func (app *application) webhookPostEvent(w http.ResponseWriter, r *http.Request) {
+	organizationId := chi.URLParam(r, "organization")
+	slug := chi.URLParam(r, "slug")
+
+	// both end up in a pocketbase filter
+	if !webhookOrganizationRX.MatchString(organizationId) || !webhookSlugRX.MatchString(slug) {
+		app.notFound(w, r)
+		return
+	}
+
+	webhook, err := app.pb.GetEnabledWebhookByOrganizationIdAndSlug(organizationId, slug)
+	if err != nil {
+		app.notFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBodySize))
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := verifyWebhookSignature(webhook, r.Header, body); err != nil {
+		app.unauthorized(w, r, err)
+		return
+	}
+
+	if webhook.SignatureScheme == "twitch" && app.webhookTwitchMessage(w, r, webhook, body) {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"body":    webhookBody(body),
+		"headers": webhookHeaders(webhook, r.Header),
+		"query":   webhookValues(r.URL.Query(), false),
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	event := model.Event{
+		OrganizationId: webhook.OrganizationId,
+		Name:           webhook.EventName,
+		Payload:        payload,
+		EmitterCode:    WebhookEmitterCode,
+		EmitterName:    webhook.Slug,
+		EmittedAt:      time.Now().Format(time.RFC3339Nano),
+	}
+
+	if err := app.validateEventPayload(&event); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	created, err := app.pb.CreateEvent(event)
+
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusCreated, created)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// webhookTwitchMessage answer the eventsub messages that are not events, twitch
+// only activate a subscription once its challenge is echoed back. It return
+// false for a notification, which is stored as an event.
+func (app *application) webhookTwitchMessage(w http.ResponseWriter, r *http.Request, webhook *model.Webhook, body []byte) bool {
+	switch r.Header.Get("Twitch-Eventsub-Message-Type") {
+	case "notification":
+		return false
+	case "webhook_callback_verification":
+		var verification struct {
+			Challenge string `json:"challenge"`
+		}
+
+		if err := json.Unmarshal(body, &verification); err != nil || verification.Challenge == "" {
+			app.badRequest(w, r, errors.New("invalid challenge"))
+			return true
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(verification.Challenge))
+	case "revocation":
+		var revocation struct {
+			Subscription struct {
+				Id     string `json:"id"`
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"subscription"`
+		}
+		_ = json.Unmarshal(body, &revocation)
+
+		app.logger.Warn(
+			"twitch subscription revoked",
+			slog.String("organization", webhook.OrganizationId),
+			slog.String("webhook", webhook.Slug),
+			slog.Group("subscription",
+				slog.String("id", revocation.Subscription.Id),
+				slog.String("type", revocation.Subscription.Type),
+				slog.String("status", revocation.Subscription.Status),
+			),
+		)
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	return true
+}
+
+// webhookBody keeps json bodies as is, anything else (form, text) as a string
+func webhookBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+
+	return string(body)
+}
+
+// webhookHeaders hide the credentials and the signature before the headers
+// are stored with the event
+func webhookHeaders(webhook *model.Webhook, header http.Header) map[string]string {
+	headers := webhookValues(header, true)
+
+	for key := range headers {
+		switch key {
+		case "authorization", "proxy-authorization", "cookie", "x-api-key", strings.ToLower(webhookSignatureHeader(webhook)):
+			headers[key] = "[redacted]"
+		}
+	}
+
+	return headers
+}
+
+func webhookValues(values map[string][]string, lowerKeys bool) map[string]string {
+	result := make(map[string]string, len(values))
+
+	for key, value := range values {
+		if lowerKeys {
+			key = strings.ToLower(key)
+		}
+		result[key] = strings.Join(value, ", ")
+	}
+
+	return result
+}
+
+// webhookSignatureHeader is the header holding the signature, stripe and
+// twitch use their own
+func webhookSignatureHeader(webhook *model.Webhook) string {
+	if webhook.SignatureHeader != "" {
+		return webhook.SignatureHeader
+	}
+
+	switch webhook.SignatureScheme {
+	case "stripe":
+		return "Stripe-Signature"
+	case "twitch":
+		return "Twitch-Eventsub-Message-Signature"
+	default:
+		return webhookDefaultSignatureHeader
+	}
+}
+
+// webhookSignedContent return what the sender signed and the signatures it
+// sent, hmac sign the raw body, stripe "<t>.<body>" and twitch
+// "<message id><timestamp><body>"
+func webhookSignedContent(webhook *model.Webhook, header http.Header, body []byte) ([]byte, [][]byte, func() hash.Hash, error) {
+	signatureHeader := header.Get(webhookSignatureHeader(webhook))
+	if signatureHeader == "" {
+		return nil, nil, nil, errors.New("missing signature")
+	}
+
+	switch webhook.SignatureScheme {
+	case "stripe":
+		// t=1492774577,v1=5257a869...,v0=6ffbb59b...
+		timestamp := ""
+		var signatures [][]byte
+
+		for _, part := range strings.Split(signatureHeader, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				if signature, err := hex.DecodeString(value); err == nil {
+					signatures = append(signatures, signature)
+				}
+			}
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || len(signatures) == 0 {
+			return nil, nil, nil, errors.New("invalid signature")
+		}
+
+		if !webhookTimestampValid(time.Unix(seconds, 0), webhookStripeTolerance) {
+			return nil, nil, nil, errors.New("signature timestamp too old")
+		}
+
+		return []byte(timestamp + "." + string(body)), signatures, sha256.New, nil
+	case "twitch":
+		messageId := header.Get("Twitch-Eventsub-Message-Id")
+		timestamp := header.Get("Twitch-Eventsub-Message-Timestamp")
+
+		sentAt, err := time.Parse(time.RFC3339Nano, timestamp)
+		if messageId == "" || err != nil {
+			return nil, nil, nil, errors.New("invalid signature")
+		}
+
+		if !webhookTimestampValid(sentAt, webhookTwitchTolerance) {
+			return nil, nil, nil, errors.New("signature timestamp too old")
+		}
+
+		signature, err := hex.DecodeString(strings.TrimPrefix(signatureHeader, "sha256="))
+		if err != nil {
+			return nil, nil, nil, errors.New("invalid signature")
+		}
+
+		return []byte(messageId + timestamp + string(body)), [][]byte{signature}, sha256.New, nil
+	}
+
+	signature, ok := strings.CutPrefix(signatureHeader, webhook.SignaturePrefix)
+	if !ok || signature == "" {
+		return nil, nil, nil, errors.New("missing signature")
+	}
+
+	var expected []byte
+	var err error
+
+	switch webhook.SignatureEncoding {
+	case "base64":
+		expected, err = base64.StdEncoding.DecodeString(signature)
+	default:
+		expected, err = hex.DecodeString(signature)
+	}
+
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid signature")
+	}
+
+	var newHash func() hash.Hash
+
+	switch webhook.SignatureAlgorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		newHash = sha256.New
+	}
+
+	return body, [][]byte{expected}, newHash, nil
+}
+
+// webhookTimestampValid refuse a replayed request, the signed timestamp must be
+// close to now
+func webhookTimestampValid(sentAt time.Time, tolerance time.Duration) bool {
+	age := time.Since(sentAt)
+	return age <= tolerance && age >= -tolerance
+}
+
+func verifyWebhookSignature(webhook *model.Webhook, header http.Header, body []byte) error {
+	if webhook.Secret == "" {
+		return nil
+	}
+
+	content, signatures, newHash, err := webhookSignedContent(webhook, header, body)
+	if err != nil {
+		return err
+	}
+
+	secrets := []string{webhook.Secret}
+
+	// during a rotation the previous secret stay valid until it expires
+	if webhook.PreviousSecret != "" {
+		expiresAt, err := types.ParseDateTime(webhook.PreviousSecretExpiresAt)
+		if err == nil && time.Now().Before(expiresAt.Time()) {
+			secrets = append(secrets, webhook.PreviousSecret)
+		}
+	}
+
+	for _, secret := range secrets {
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write(content)
+		sum := mac.Sum(nil)
+
+		for _, signature := range signatures {
+			if hmac.Equal(sum, signature) {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("invalid signature")
+}
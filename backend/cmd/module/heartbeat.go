@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/moduletoken"
+	"github.com/sourcegraph/jsonrpc2"
+	"log/slog"
+	"time"
+)
+
+const (
+	SystemEmitterCode = "system"
+
+	moduleConnectedEvent    = "module.connected"
+	moduleDisconnectedEvent = "module.disconnected"
+)
+
+// heartbeat ping the module until its websocket close, after missed pings in
+// a row the websocket is closed : a half-open connection would else stay
+// connected forever. Any answer count, a module without session.ping reply
+// method not found and is alive
+func (app *application) heartbeat(client *jsonrpc2.Conn) {
+	if app.config.heartbeatInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.heartbeatInterval)
+	defer ticker.Stop()
+
+	missed := 0
+
+	for {
+		select {
+		case <-client.DisconnectNotify():
+			return
+		case <-ticker.C:
+		}
+
+		// not registered yet, rpc close it if it never does
+		session := app.GetSession(client)
+		if session == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), app.config.heartbeatInterval)
+		var result any
+		err := client.Call(ctx, "session.ping", nil, &result)
+		cancel()
+
+		var rpcError *jsonrpc2.Error
+		if err == nil || errors.As(err, &rpcError) {
+			missed = 0
+			if err := app.pb.UpdateModuleLastSeen(session.Module.Id); err != nil {
+				app.logger.Error("can't update module last seen", slog.String("error", err.Error()))
+			}
+
+			// a token rotated without grace period is revoked and ejected
+			// right away, one past its expiry is noticed here
+			if app.tokenRevoked(session) {
+				app.sessionsMu.RLock()
+				link := app.links[session.Module.SessionId]
+				app.sessionsMu.RUnlock()
+
+				if link != nil {
+					app.closeSession(link, false, "revoked")
+				}
+				_ = client.Close()
+				return
+			}
+			continue
+		}
+
+		missed++
+		if missed < app.config.heartbeatMaxMissed {
+			continue
+		}
+
+		app.logger.Warn(
+			"module missed its heartbeats",
+			slog.Group("module",
+				slog.String("organization", session.Module.OrganizationId),
+				slog.String("code", session.Module.Code),
+				slog.String("name", session.Module.Name),
+			),
+			slog.Int("missed", missed),
+		)
+
+		_ = client.Close()
+		return
+	}
+}
+
+func (app *application) tokenRevoked(session *model.ModuleSession) bool {
+	if session.Token == nil {
+		return false
+	}
+
+	token, err := app.pb.GetModuleToken(session.Token.Id)
+	if err != nil {
+		return false
+	}
+
+	return token.RevokedAt != "" || moduletoken.Expired(token.ExpiresAt, time.Now())
+}
+
+// emitModuleEvent create a module.connected or module.disconnected event, the
+// triggers react to it like any other event
+func (app *application) emitModuleEvent(name string, session *model.ModuleSession, reason string) {
+	data := map[string]any{
+		"id":      session.Module.Id,
+		"code":    session.Module.Code,
+		"name":    session.Module.Name,
+		"session": session.Module.SessionId,
+	}
+
+	if reason != "" {
+		data["reason"] = reason
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	event := model.Event{
+		OrganizationId: session.Module.OrganizationId,
+		Name:           name,
+		Payload:        payload,
+		EmitterCode:    SystemEmitterCode,
+		EmitterName:    session.Module.Code,
+		EmittedAt:      time.Now().Format(time.RFC3339Nano),
+	}
+
+	if err := app.validateEventPayload(&event); err != nil {
+		app.logger.Error("can't emit module event", slog.String("event", name), slog.String("error", err.Error()))
+		return
+	}
+
+	if _, err := app.pb.CreateEvent(event); err != nil {
+		app.logger.Error("can't emit module event", slog.String("event", name), slog.String("error", err.Error()))
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"runtime/debug"
 	"sync"
+	"time"
 )
 
 func main() {
@@ -33,6 +34,11 @@ type config struct {
 	pocketBaseURL           string
 	pocketBaseAdminEmail    string
 	pocketBaseAdminPassword string
+	sessionGracePeriod      time.Duration
+	heartbeatInterval       time.Duration
+	heartbeatMaxMissed      int
+	sessionSweepInterval    time.Duration
+	nodeId                  string
 }
 
 type application struct {
@@ -42,6 +48,7 @@ type application struct {
 	logger     *slog.Logger
 	upgrader   websocket.Upgrader
 	sessions   map[*jsonrpc2.Conn]*model.ModuleSession
+	links      map[string]*moduleLink
 	sessionsMu sync.RWMutex
 }
 
@@ -54,6 +61,11 @@ func run(logger *slog.Logger) error {
 	cfg.pocketBaseAdminEmail = env.GetString("POCKETBASE_ADMIN_EMAIL", "admin@admin.com")
 	cfg.pocketBaseAdminPassword = env.GetString("POCKETBASE_ADMIN_PASSWORD", "admin")
 	cfg.natsUrl = env.GetString("NATS_URL", nats.DefaultURL)
+	cfg.sessionGracePeriod = time.Duration(env.GetInt("SESSION_GRACE_PERIOD", 30000)) * time.Millisecond
+	cfg.heartbeatInterval = time.Duration(env.GetInt("HEARTBEAT_INTERVAL", 15000)) * time.Millisecond
+	cfg.heartbeatMaxMissed = env.GetInt("HEARTBEAT_MAX_MISSED", 3)
+	cfg.sessionSweepInterval = time.Duration(env.GetInt("SESSION_SWEEP_INTERVAL", 60000)) * time.Millisecond
+	cfg.nodeId = env.GetString("NODE_ID", defaultNodeId())
 
 	app := &application{
 		config:     cfg,
@@ -61,6 +73,7 @@ func run(logger *slog.Logger) error {
 		realtime:   realtime.NewRealtimeClient(cfg.natsUrl),
 		pb:         database.NewPocketBaseClient(cfg.pocketBaseURL, cfg.pocketBaseAdminEmail, cfg.pocketBaseAdminPassword),
 		sessions:   make(map[*jsonrpc2.Conn]*model.ModuleSession),
+		links:      make(map[string]*moduleLink),
 		sessionsMu: sync.RWMutex{},
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool { return true },
@@ -69,3 +82,13 @@ func run(logger *slog.Logger) error {
 
 	return app.serveHTTP()
 }
+
+// defaultNodeId is the hostname, a replica restarted with the same one reclaim
+// the sessions it held. The others are removed by sweepSessions
+func defaultNodeId() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "module"
+	}
+	return hostname
+}
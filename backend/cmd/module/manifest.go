@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/schema"
+)
+
+// validateManifest check the manifest a module send at session.register, the
+// names must be unique and every schema must compile
+func validateManifest(manifest *model.ModuleManifest) error {
+	if manifest == nil {
+		return nil
+	}
+
+	if len(manifest.Version) > 50 {
+		return fmt.Errorf("version is too long")
+	}
+
+	methods := make(map[string]bool)
+	for i, method := range manifest.Methods {
+		if method.Name == "" || len(method.Name) > 100 {
+			return fmt.Errorf("methods[%d] : name must be between 1 and 100 characters", i)
+		}
+
+		if methods[method.Name] {
+			return fmt.Errorf("methods[%d] : %s is declared twice", i, method.Name)
+		}
+		methods[method.Name] = true
+
+		if err := compileManifestSchema(method.Params); err != nil {
+			return fmt.Errorf("methods[%d] : invalid params schema : %s", i, err.Error())
+		}
+
+		if err := compileManifestSchema(method.Result); err != nil {
+			return fmt.Errorf("methods[%d] : invalid result schema : %s", i, err.Error())
+		}
+	}
+
+	events := make(map[string]bool)
+	for i, event := range manifest.Events {
+		if len(event.Name) < 3 || len(event.Name) > 100 || !eventNameRX.MatchString(event.Name) {
+			return fmt.Errorf("events[%d] : invalid name %s", i, event.Name)
+		}
+
+		if events[event.Name] {
+			return fmt.Errorf("events[%d] : %s is declared twice", i, event.Name)
+		}
+		events[event.Name] = true
+
+		if err := compileManifestSchema(event.Payload); err != nil {
+			return fmt.Errorf("events[%d] : invalid payload schema : %s", i, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func compileManifestSchema(data json.RawMessage) error {
+	// no schema or null, anything is accepted
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	_, err := schema.Compile(data)
+	return err
+}
@@ -17,6 +17,7 @@ func (app *application) routes() http.Handler {
 	mux.Get("/health", app.healthcheck)
 	mux.Get("/", app.rpc)
 	mux.Post("/", app.modulePostEvent)
+	mux.Post("/hooks/{organization}/{slug}", app.webhookPostEvent)
 
 	return mux
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/schema"
+	"strings"
+)
+
+// validateEventPayload check the payload against the schema registered for the
+// event name, in FLAG mode the errors are saved with the event instead
+func (app *application) validateEventPayload(event *model.Event) error {
+	eventSchema, err := app.pb.GetEventSchemaByOrganizationIdAndName(event.OrganizationId, event.Name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	schemaErrors, err := schema.Validate(eventSchema.Schema, event.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid schema for event %s : %s", event.Name, err.Error())
+	}
+
+	if len(schemaErrors) == 0 {
+		return nil
+	}
+
+	if eventSchema.Mode == model.EventSchemaModeFlag {
+		event.SchemaErrors = schemaErrors
+		return nil
+	}
+
+	return fmt.Errorf("payload doesn't match the %s schema : %s", event.Name, strings.Join(schemaErrors, ", "))
+}
@@ -44,10 +44,12 @@ func (app *application) serveHTTP() error {
 
 	app.logger.Info("starting server", slog.Group("server", "addr", srv.Addr))
 
-	if err := app.pb.ResetAllModuleSession(); err != nil {
+	if err := app.reclaimNodeSessions(); err != nil {
 		return err
 	}
 
+	go app.sweepSessions()
+
 	err := srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -2,81 +2,338 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"github.com/evntboard/app/backend/internal/model"
 	"github.com/nats-io/nats.go"
+	"github.com/pocketbase/pocketbase/tools/security"
 	"github.com/sourcegraph/jsonrpc2"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
 )
 
-func (app *application) AddSession(client *jsonrpc2.Conn, module *model.Module) error {
-	if err := app.pb.GenerateModuleSession(module); err != nil {
+const resumeTokenLength = 50
+
+var errSessionRegistered = errors.New("session already registered")
+
+// moduleLink route the nats messages of a session to the websocket of the
+// module. While a resumable module is away the messages wait for it, they are
+// replayed when it resume or refused when the grace period is over
+type moduleLink struct {
+	session       *model.ModuleSession
+	resumable     bool
+	subscriptions []*nats.Subscription
+
+	mu         sync.Mutex
+	conn       *jsonrpc2.Conn
+	pending    []*nats.Msg
+	expire     *time.Timer
+	closed     bool
+	handedOver bool
+	// resuming is true while the replica holding the session has not yet
+	// accepted the resume token, every message wait
+	resuming bool
+}
+
+func (link *moduleLink) attached() *jsonrpc2.Conn {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+	return link.conn
+}
+
+// hold keep a message until the module resume, false when the session is
+// closed or when conn was already replaced by a resumed one
+func (link *moduleLink) hold(conn *jsonrpc2.Conn, msg *nats.Msg) bool {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.closed || (link.conn != nil && link.conn != conn) {
+		return false
+	}
+
+	link.pending = append(link.pending, msg)
+	return true
+}
+
+// release detach the websocket that just disconnected, keep is false when the
+// session must be closed now
+func (link *moduleLink) release(conn *jsonrpc2.Conn, grace time.Duration, onExpire func()) (keep bool, detached bool) {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.closed {
+		return true, false
+	}
+
+	// a resumed session already moved to another websocket
+	if link.conn != conn {
+		return true, false
+	}
+
+	if !link.resumable || grace <= 0 {
+		return false, false
+	}
+
+	link.conn = nil
+	link.expire = time.AfterFunc(grace, onExpire)
+	return true, true
+}
+
+// attach move the session on the websocket of the resumed module, it return the
+// previous websocket if the module came back before its drop was noticed
+func (link *moduleLink) attach(conn *jsonrpc2.Conn, token string) (*jsonrpc2.Conn, []*nats.Msg, bool) {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.closed {
+		return nil, nil, false
+	}
+
+	if link.expire != nil {
+		link.expire.Stop()
+		link.expire = nil
+	}
+
+	previous := link.conn
+	pending := link.pending
+
+	link.conn = conn
+	link.pending = nil
+	link.resuming = false
+	link.session.ResumeToken = token
+
+	return previous, pending, true
+}
+
+// close mark the session closed, a detached only close keep a session that
+// has a websocket
+func (link *moduleLink) close(detachedOnly bool) ([]*nats.Msg, bool) {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.closed || (detachedOnly && link.conn != nil) {
+		return nil, false
+	}
+
+	if link.expire != nil {
+		link.expire.Stop()
+		link.expire = nil
+	}
+
+	pending := link.pending
+	link.closed = true
+	link.pending = nil
+
+	return pending, true
+}
+
+// handover close the session for the replica where the module resumed, the
+// messages kept so far move with it
+func (link *moduleLink) handover(token string) (*jsonrpc2.Conn, []*nats.Msg, bool) {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.closed || !link.tokenEqual(token) {
+		return nil, nil, false
+	}
+
+	if link.expire != nil {
+		link.expire.Stop()
+		link.expire = nil
+	}
+
+	conn := link.conn
+	pending := link.pending
+
+	link.conn = nil
+	link.pending = nil
+	link.closed = true
+	link.handedOver = true
+
+	return conn, pending, true
+}
+
+// abandon give up a resume refused by the replica holding the session, the
+// messages received meanwhile go back to it
+func (link *moduleLink) abandon() []*nats.Msg {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	pending := link.pending
+
+	link.pending = nil
+	link.closed = true
+	link.handedOver = true
+
+	return pending
+}
+
+func (link *moduleLink) isResuming() bool {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+	return link.resuming
+}
+
+// forwarded is true once the session moved to another replica
+func (link *moduleLink) forwarded() bool {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+	return link.handedOver
+}
+
+func (link *moduleLink) matchToken(token string) bool {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	return !link.closed && link.tokenEqual(token)
+}
+
+func (link *moduleLink) tokenEqual(token string) bool {
+	return link.session.ResumeToken != "" &&
+		subtle.ConstantTimeCompare([]byte(link.session.ResumeToken), []byte(token)) == 1
+}
+
+// newResumeToken start with the session, a replica that doesn't hold the
+// session know where to ask for it
+func newResumeToken(session string) string {
+	return session + "." + security.RandomString(resumeTokenLength)
+}
+
+// sessionFromResumeToken return the session of a token
+func sessionFromResumeToken(token string) string {
+	session, _, found := strings.Cut(token, ".")
+	if !found {
+		return ""
+	}
+	return session
+}
+
+func (app *application) AddSession(client *jsonrpc2.Conn, module *model.Module, token *model.ModuleToken, resumable bool) error {
+	if err := app.pb.GenerateModuleSession(module, token, app.config.nodeId); err != nil {
+		return err
+	}
+
+	session := &model.ModuleSession{
+		Module: module,
+		Token:  token,
+	}
+
+	if resumable {
+		session.ResumeToken = newResumeToken(module.SessionId)
+	}
+
+	link := &moduleLink{
+		session:   session,
+		resumable: resumable,
+		conn:      client,
+	}
+
+	if err := app.subscribeSession(link); err != nil {
+		return err
+	}
+
+	if err := app.subscribeSessionControl(link); err != nil {
+		link.unsubscribe()
 		return err
 	}
 
 	app.sessionsMu.Lock()
-	defer app.sessionsMu.Unlock()
+	// a register running at the same time on this websocket won
+	if _, ok := app.sessions[client]; ok {
+		app.sessionsMu.Unlock()
+		link.unsubscribe()
+		if err := app.pb.RemoveModuleSession(module, module.SessionId); err != nil {
+			app.logger.Error("can't remove module session", slog.String("error", err.Error()))
+		}
+		return errSessionRegistered
+	}
+	app.sessions[client] = session
+	app.links[module.SessionId] = link
+	app.sessionsMu.Unlock()
 
-	sub, err := app.realtime.Subscribe(app.realtime.GetChannelForModule(module.SessionId), func(msg *nats.Msg) {
-		var data map[string]any
+	if err := app.pb.UpdateModuleLastSeen(module.Id); err != nil {
+		app.logger.Error("can't update module last seen", slog.String("error", err.Error()))
+	}
 
-		msgErrorJson, _ := json.Marshal(map[string]any{
-			"error": "not valid message",
-		})
+	app.emitModuleEvent(moduleConnectedEvent, session, "")
 
-		if err := json.Unmarshal(msg.Data, &data); err != nil {
-			_ = app.realtime.Publish(msg.Reply, msgErrorJson)
+	return nil
+}
+
+func (app *application) onModuleMessage(link *moduleLink, msg *nats.Msg) {
+	if link.forwarded() {
+		app.forwardModuleMessage(msg)
+		return
+	}
+
+	var data map[string]any
+
+	msgErrorJson, _ := json.Marshal(map[string]any{
+		"error": "not valid message",
+	})
+
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		_ = app.realtime.Publish(msg.Reply, msgErrorJson)
+		return
+	}
+
+	typeMsg, ok := data["type"]
+
+	if !ok {
+		_ = app.realtime.Publish(msg.Reply, msgErrorJson)
+		return
+	}
+
+	client := link.attached()
+
+	if client == nil {
+		// an eject doesn't wait for the module to come back
+		if typeMsg == "module" && data["action"] == "eject" && !link.isResuming() {
+			app.closeSession(link, false, ejectReason(data))
+			_ = app.realtime.Publish(msg.Reply, nil)
 			return
 		}
 
-		typeMsg, ok := data["type"]
+		app.holdModuleMessage(link, nil, msg)
+		return
+	}
 
+	if typeMsg == "module" {
+		actionMsg, ok := data["action"]
 		if !ok {
 			_ = app.realtime.Publish(msg.Reply, msgErrorJson)
 			return
 		}
 
-		if typeMsg == "module" {
-			actionMsg, ok := data["action"]
+		if actionMsg == "request" {
+			payload, ok := data["payload"].(map[string]any)
+
 			if !ok {
 				_ = app.realtime.Publish(msg.Reply, msgErrorJson)
 				return
 			}
 
-			if actionMsg == "request" {
-				payload, ok := data["payload"].(map[string]any)
-
-				if !ok {
-					_ = app.realtime.Publish(msg.Reply, msgErrorJson)
-					return
-				}
-
-				var result any
-				err := client.Call(
-					context.Background(),
-					payload["method"].(string),
-					payload["params"],
-					&result,
-				)
-				if err != nil {
-					msgJson, err := json.Marshal(map[string]any{
-						"error": err,
-					})
-
-					if err != nil {
-						_ = app.realtime.Publish(msg.Reply, nil)
-						return
-					}
-
-					_ = app.realtime.Publish(msg.Reply, msgJson)
-					return
-				}
-
+			var result any
+			err := client.Call(
+				context.Background(),
+				payload["method"].(string),
+				payload["params"],
+				&result,
+			)
+			if errors.Is(err, jsonrpc2.ErrClosed) && link.resumable {
+				app.holdModuleMessage(link, client, msg)
+				return
+			}
+			if err != nil {
 				msgJson, err := json.Marshal(map[string]any{
-					"success": result,
+					"error": err,
 				})
 
 				if err != nil {
-					_ = app.realtime.Publish(msg.Reply, msgErrorJson)
+					_ = app.realtime.Publish(msg.Reply, nil)
 					return
 				}
 
@@ -84,73 +341,226 @@ func (app *application) AddSession(client *jsonrpc2.Conn, module *model.Module)
 				return
 			}
 
-			if actionMsg == "notify" {
-				payload, ok := data["payload"].(map[string]any)
+			msgJson, err := json.Marshal(map[string]any{
+				"success": result,
+			})
 
-				if !ok {
-					return
-				}
-				_ = client.Notify(
-					context.Background(),
-					payload["method"].(string),
-					payload["params"],
-				)
+			if err != nil {
+				_ = app.realtime.Publish(msg.Reply, msgErrorJson)
 				return
 			}
 
-			if actionMsg == "eject" {
-				client.Close()
-				_ = app.realtime.Publish(msg.Reply, nil)
-				return
-			}
+			_ = app.realtime.Publish(msg.Reply, msgJson)
+			return
 		}
 
-		if typeMsg == "storage" {
+		if actionMsg == "notify" {
 			payload, ok := data["payload"].(map[string]any)
+
 			if !ok {
 				return
 			}
-			_ = client.Notify(
+			err := client.Notify(
 				context.Background(),
-				"storage.sync",
-				payload,
+				payload["method"].(string),
+				payload["params"],
 			)
+			if errors.Is(err, jsonrpc2.ErrClosed) && link.resumable {
+				app.holdModuleMessage(link, client, msg)
+			}
+			return
+		}
+
+		if actionMsg == "eject" {
+			// an ejected module must register again, no grace period
+			app.closeSession(link, false, ejectReason(data))
+			client.Close()
+			_ = app.realtime.Publish(msg.Reply, nil)
+			return
+		}
+	}
+
+	if typeMsg == "storage" {
+		payload, ok := data["payload"].(map[string]any)
+		if !ok {
 			return
 		}
+		err := client.Notify(
+			context.Background(),
+			"storage.sync",
+			payload,
+		)
+		if errors.Is(err, jsonrpc2.ErrClosed) && link.resumable {
+			app.holdModuleMessage(link, client, msg)
+		}
+		return
+	}
+}
+
+// holdModuleMessage keep a message sent while the module is away, conn is the
+// websocket that failed to deliver it
+func (app *application) holdModuleMessage(link *moduleLink, conn *jsonrpc2.Conn, msg *nats.Msg) {
+	if link.hold(conn, msg) {
+		return
+	}
+
+	if link.forwarded() {
+		app.forwardModuleMessage(msg)
+		return
+	}
+
+	// the module already resumed on another websocket
+	if current := link.attached(); current != nil && current != conn {
+		go app.onModuleMessage(link, msg)
+		return
+	}
+
+	app.replyModuleDisconnected(msg)
+}
+
+func (app *application) replyModuleDisconnected(msg *nats.Msg) {
+	if msg.Reply == "" {
+		return
+	}
+
+	msgJson, _ := json.Marshal(map[string]any{
+		"error": "module disconnected",
 	})
+	_ = app.realtime.Publish(msg.Reply, msgJson)
+}
 
-	if err != nil {
-		return err
+// ResumeSession attach a session waiting in its grace period to a new
+// websocket, the messages sent in between are replayed
+func (app *application) ResumeSession(client *jsonrpc2.Conn, token string) (*model.ModuleSession, bool) {
+	app.sessionsMu.RLock()
+	var link *moduleLink
+	for _, current := range app.links {
+		if current.matchToken(token) {
+			link = current
+			break
+		}
 	}
+	app.sessionsMu.RUnlock()
 
-	app.sessions[client] = &model.ModuleSession{
-		Module:       module,
-		Subscription: sub,
+	// the session may be held by another replica
+	if link == nil {
+		return app.resumeRemoteSession(client, token)
 	}
 
-	return nil
+	previous, pending, ok := link.attach(client, newResumeToken(link.session.Module.SessionId))
+	if !ok {
+		return nil, false
+	}
+
+	app.sessionsMu.Lock()
+	app.sessions[client] = link.session
+	app.sessionsMu.Unlock()
+
+	if err := app.pb.UpdateModuleSessionStatus(link.session.Module.SessionId, model.ModuleInstanceStatusConnected); err != nil {
+		app.logger.Error("can't update module session status", slog.String("error", err.Error()))
+	}
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+
+	app.logger.Info(
+		"module session resumed",
+		slog.Group("module",
+			slog.String("organization", link.session.Module.OrganizationId),
+			slog.String("code", link.session.Module.Code),
+			slog.String("name", link.session.Module.Name),
+		),
+		slog.Int("pending", len(pending)),
+	)
+
+	go func() {
+		for _, msg := range pending {
+			app.onModuleMessage(link, msg)
+		}
+	}()
+
+	return link.session, true
 }
 
 func (app *application) RemoveSession(client *jsonrpc2.Conn) {
 	app.sessionsMu.Lock()
-	defer app.sessionsMu.Unlock()
-
-	session := app.GetSession(client)
+	session, ok := app.sessions[client]
+	if !ok {
+		app.sessionsMu.Unlock()
+		return
+	}
+	delete(app.sessions, client)
+	link := app.links[session.Module.SessionId]
+	app.sessionsMu.Unlock()
 
-	if session == nil {
+	if link == nil {
 		return
 	}
 
-	if err := app.pb.ResetModuleSessionByModuleId(session.Module.Id); err != nil {
+	keep, detached := link.release(client, app.config.sessionGracePeriod, func() { app.closeSession(link, true, "expired") })
+
+	// other instances of the module get the requests while this one is away
+	if detached {
+		if err := app.pb.UpdateModuleSessionStatus(session.Module.SessionId, model.ModuleInstanceStatusDetached); err != nil {
+			app.logger.Error("can't update module session status", slog.String("error", err.Error()))
+		}
+	}
+
+	if keep {
 		return
 	}
 
-	_ = session.Subscription.Unsubscribe()
+	app.closeSession(link, false, "disconnected")
+}
 
-	delete(app.sessions, client)
+// closeSession end the session for good, the reason is given to the triggers
+// with the module.disconnected event
+func (app *application) closeSession(link *moduleLink, detachedOnly bool, reason string) bool {
+	pending, ok := link.close(detachedOnly)
+	if !ok {
+		return false
+	}
+
+	app.sessionsMu.Lock()
+	delete(app.links, link.session.Module.SessionId)
+	for conn, session := range app.sessions {
+		if session == link.session {
+			delete(app.sessions, conn)
+		}
+	}
+	app.sessionsMu.Unlock()
+
+	link.unsubscribe()
+
+	// a row left behind is removed later by sweepSessions
+	if err := app.pb.RemoveModuleSession(link.session.Module, link.session.Module.SessionId); err != nil {
+		app.logger.Error("can't remove module session", slog.String("error", err.Error()))
+	}
+
+	// the requests still waiting would otherwise run until their timeout
+	for _, msg := range pending {
+		app.replyModuleDisconnected(msg)
+	}
+
+	app.emitModuleEvent(moduleDisconnectedEvent, link.session, reason)
+
+	return true
+}
+
+// ejectReason is "revoked" when the token of the session was revoked, else
+// "ejected"
+func ejectReason(data map[string]any) string {
+	if reason, ok := data["reason"].(string); ok && reason != "" {
+		return reason
+	}
+	return "ejected"
 }
 
 func (app *application) GetSession(client *jsonrpc2.Conn) *model.ModuleSession {
+	app.sessionsMu.RLock()
+	defer app.sessionsMu.RUnlock()
+
 	if currentClient, ok := app.sessions[client]; ok {
 		return currentClient
 	}
@@ -4,6 +4,7 @@ go 1.21.5
 
 require (
 	github.com/dop251/goja v0.0.0-20240220182346-e401ed450204
+	github.com/evanw/esbuild v0.20.2
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/go-ozzo/ozzo-validation/v4 v4.3.0
 	github.com/google/uuid v1.6.0
@@ -13,6 +14,7 @@ require (
 	github.com/pluja/pocketbase v0.0.61
 	github.com/pocketbase/dbx v1.10.1
 	github.com/pocketbase/pocketbase v0.22.9
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/sourcegraph/conc v0.3.0
 	github.com/sourcegraph/jsonrpc2 v0.2.0
 	github.com/tomasen/realip v0.0.0-20180522021738-f0c99a92ddce
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/pluja/pocketbase"
+)
+
+func (c *PocketBaseClient) GetEventSchemaByOrganizationIdAndName(organizationId, name string) (*model.EventSchema, error) {
+	collection := pocketbase.CollectionSet[model.EventSchema](c.pb, "event_schemas")
+
+	strFilter := fmt.Sprintf("organization = \"%s\" && name = \"%s\"", organizationId, name)
+	response, err := collection.List(pocketbase.ParamsList{
+		Size:    1,
+		Page:    0,
+		Sort:    "+created",
+		Filters: strFilter,
+		Expand:  "",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.TotalItems == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &response.Items[0], nil
+}
@@ -4,52 +4,189 @@ import (
 	"errors"
 	"fmt"
 	"github.com/evntboard/app/backend/internal/model"
+	"github.com/evntboard/app/backend/internal/moduletoken"
 	"github.com/google/uuid"
 	"github.com/pluja/pocketbase"
-	"log"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"time"
 )
 
-func (c *PocketBaseClient) ResetModuleSessionByModuleId(moduleId string) error {
-	err := c.pb.Update(
+// RemoveModuleSession delete an instance, the session of the module move to
+// another instance or is cleared when it was the last one
+func (c *PocketBaseClient) RemoveModuleSession(module *model.Module, session string) error {
+	instances, err := c.GetModuleInstances(module.Id)
+	if err != nil {
+		return err
+	}
+
+	next := ""
+	for _, instance := range instances {
+		if instance.Session == session {
+			if err := c.pb.Delete("module_sessions", instance.Id); err != nil {
+				return err
+			}
+			continue
+		}
+		if next == "" {
+			next = instance.Session
+		}
+	}
+
+	var value any
+	if next != "" {
+		value = next
+	}
+
+	return c.pb.Update(
 		"modules",
-		moduleId,
+		module.Id,
 		map[string]any{
-			"session": nil,
+			"session": value,
 		},
 	)
+}
+
+func (c *PocketBaseClient) GetModuleInstances(moduleId string) ([]model.ModuleInstance, error) {
+	collection := pocketbase.CollectionSet[model.ModuleInstance](c.pb, "module_sessions")
+
+	response, err := collection.List(pocketbase.ParamsList{
+		Size:    500,
+		Page:    0,
+		Sort:    "+created",
+		Filters: fmt.Sprintf("module = \"%s\"", moduleId),
+		Expand:  "",
+	})
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return nil
+	return response.Items, nil
 }
 
-func (c *PocketBaseClient) GetModuleByCodeNameToken(code, name, token string) (*model.Module, error) {
-	collection := pocketbase.CollectionSet[model.Module](c.pb, "modules")
+func (c *PocketBaseClient) UpdateModuleSessionStatus(session string, status string) error {
+	collection := pocketbase.CollectionSet[model.ModuleInstance](c.pb, "module_sessions")
 
-	strFilter := fmt.Sprintf("code = \"%s\" && name = \"%s\" && token = \"%s\"", code, name, token)
 	response, err := collection.List(pocketbase.ParamsList{
+		Size:    1,
+		Page:    0,
+		Sort:    "+created",
+		Filters: fmt.Sprintf("session = \"%s\"", session),
+		Expand:  "",
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if response.TotalItems == 0 {
+		return errors.New("not found")
+	}
+
+	return c.pb.Update(
+		"module_sessions",
+		response.Items[0].Id,
+		map[string]any{
+			"status": status,
+		},
+	)
+}
+
+// ClaimModuleSession move an instance to the node where its module resumed
+func (c *PocketBaseClient) ClaimModuleSession(session string, node string) error {
+	collection := pocketbase.CollectionSet[model.ModuleInstance](c.pb, "module_sessions")
+
+	response, err := collection.List(pocketbase.ParamsList{
+		Size:    1,
+		Page:    0,
+		Sort:    "+created",
+		Filters: fmt.Sprintf("session = \"%s\"", session),
+		Expand:  "",
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if response.TotalItems == 0 {
+		return errors.New("not found")
+	}
+
+	return c.pb.Update(
+		"module_sessions",
+		response.Items[0].Id,
+		map[string]any{
+			"status": model.ModuleInstanceStatusConnected,
+			"node":   node,
+		},
+	)
+}
+
+// GetModuleByCodeNameToken find the module and the token it authenticate with,
+// a revoked or expired token is refused. The token hash is unique so it give
+// the module, code and name are only checked against it.
+func (c *PocketBaseClient) GetModuleByCodeNameToken(code, name, token string) (*model.Module, *model.ModuleToken, error) {
+	tokens := pocketbase.CollectionSet[model.ModuleToken](c.pb, "module_tokens")
+
+	strFilter := fmt.Sprintf("hash = \"%s\" && revoked_at = \"\"", moduletoken.Hash(token))
+	response, err := tokens.List(pocketbase.ParamsList{
 		Size:    1,
 		Page:    0,
 		Sort:    "+created",
 		Filters: strFilter,
-		Expand:  "module_params_via_module",
+		Expand:  "module.module_params_via_module,module.module_sessions_via_module",
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
-	if response.TotalItems != 1 {
-		return nil, errors.New("not found")
+	if response.TotalItems != 1 || moduletoken.Expired(response.Items[0].ExpiresAt, time.Now()) {
+		return nil, nil, errors.New("not found")
 	}
 
-	return &response.Items[0], nil
+	moduleToken := &response.Items[0]
+	module := moduleToken.Expand.Module
+
+	if module == nil || module.Code != code || module.Name != name {
+		return nil, nil, errors.New("not found")
+	}
+
+	return module, moduleToken, nil
+}
+
+// GetModuleToken is used to notice a token revoked or expired while a session
+// use it
+func (c *PocketBaseClient) GetModuleToken(tokenId string) (*model.ModuleToken, error) {
+	collection := pocketbase.CollectionSet[model.ModuleToken](c.pb, "module_tokens")
+
+	one, err := collection.One(tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &one, nil
 }
 
-func (c *PocketBaseClient) GenerateModuleSession(module *model.Module) error {
+// GenerateModuleSession create a new instance of the module held by the node,
+// the module keep the session of one of its instances while one is connected
+func (c *PocketBaseClient) GenerateModuleSession(module *model.Module, token *model.ModuleToken, node string) error {
 	newModuleSession := uuid.NewString()
+
+	_, err := c.pb.Create(
+		"module_sessions",
+		map[string]any{
+			"module":  module.Id,
+			"session": newModuleSession,
+			"status":  model.ModuleInstanceStatusConnected,
+			"node":    node,
+			"token":   token.Id,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
 	module.SessionId = newModuleSession
 	return c.pb.Update(
 		"modules",
@@ -60,6 +197,29 @@ func (c *PocketBaseClient) GenerateModuleSession(module *model.Module) error {
 	)
 }
 
+func (c *PocketBaseClient) UpdateModuleLastSeen(moduleId string) error {
+	return c.pb.Update(
+		"modules",
+		moduleId,
+		map[string]any{
+			"last_seen_at": time.Now().Format(time.RFC3339Nano),
+		},
+	)
+}
+
+// UpdateModuleManifest replace the manifest of the module, a module that
+// register without one clear the previous
+func (c *PocketBaseClient) UpdateModuleManifest(module *model.Module, manifest *model.ModuleManifest) error {
+	module.Manifest = manifest
+	return c.pb.Update(
+		"modules",
+		module.Id,
+		map[string]any{
+			"manifest": manifest,
+		},
+	)
+}
+
 func (c *PocketBaseClient) GetModuleWithSessionByOrganizationIdAndNameOrCode(organizationId, name string) (*model.Module, error) {
 	collection := pocketbase.CollectionSet[model.Module](c.pb, "modules")
 
@@ -69,7 +229,7 @@ func (c *PocketBaseClient) GetModuleWithSessionByOrganizationIdAndNameOrCode(org
 		Page:    0,
 		Sort:    "+created",
 		Filters: strFilter,
-		Expand:  "",
+		Expand:  "module_sessions_via_module",
 	})
 
 	if err != nil {
@@ -83,32 +243,47 @@ func (c *PocketBaseClient) GetModuleWithSessionByOrganizationIdAndNameOrCode(org
 	return &response.Items[0], nil
 }
 
-func (c *PocketBaseClient) ResetAllModuleSession() error {
-	collection := pocketbase.CollectionSet[model.Module](c.pb, "modules")
+// GetModuleInstancesCreatedBefore return every instance old enough to be
+// probed, a younger one may still be subscribing on its replica
+func (c *PocketBaseClient) GetModuleInstancesCreatedBefore(before time.Time) ([]model.ModuleInstance, error) {
+	collection := pocketbase.CollectionSet[model.ModuleInstance](c.pb, "module_sessions")
+
+	return listAll(collection, pocketbase.ParamsList{
+		Size:    500,
+		Sort:    "+created",
+		Filters: fmt.Sprintf("created < \"%s\"", before.UTC().Format(types.DefaultDateLayout)),
+		Expand:  "module",
+	})
+}
+
+// ResetModuleSessionsByNode remove the instances a node held before it
+// restarted, the instances of the other nodes stay
+func (c *PocketBaseClient) ResetModuleSessionsByNode(node string) ([]model.ModuleInstance, error) {
+	collection := pocketbase.CollectionSet[model.ModuleInstance](c.pb, "module_sessions")
 	response, err := collection.List(pocketbase.ParamsList{
 		Size:    500,
 		Page:    0,
 		Sort:    "+created",
-		Filters: "session != \"\"",
-		Expand:  "",
+		Filters: fmt.Sprintf("node = \"%s\"", node),
+		Expand:  "module",
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	for _, item := range response.Items {
-		err := c.pb.Update(
-			"modules",
-			item.Id,
-			map[string]any{
-				"session": nil,
-			},
-		)
-		if err != nil {
-			return err
+		if item.Expand.Module == nil {
+			if err := c.pb.Delete("module_sessions", item.Id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := c.RemoveModuleSession(item.Expand.Module, item.Session); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return response.Items, nil
 }
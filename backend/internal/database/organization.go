@@ -0,0 +1,17 @@
+package database
+
+import (
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/pluja/pocketbase"
+)
+
+func (c *PocketBaseClient) GetOrganizationById(organizationId string) (*model.Organization, error) {
+	collection := pocketbase.CollectionSet[model.Organization](c.pb, "organizations")
+
+	one, err := collection.One(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &one, nil
+}
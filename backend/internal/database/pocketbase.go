@@ -19,3 +19,23 @@ func NewPocketBaseClient(pocketBaseURL, pocketBaseAdminEmail, pocketBaseAdminPas
 		pb: client,
 	}
 }
+
+// listAll go through every page, a single page stop at its size
+func listAll[T any](collection *pocketbase.Collection[T], params pocketbase.ParamsList) ([]T, error) {
+	var items []T
+
+	for page := 1; ; page++ {
+		params.Page = page
+
+		response, err := collection.List(params)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, response.Items...)
+
+		if page >= response.TotalPages {
+			return items, nil
+		}
+	}
+}
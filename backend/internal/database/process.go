@@ -1,6 +1,10 @@
 package database
 
 import (
+	"database/sql"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/pluja/pocketbase"
 	"time"
 )
 
@@ -21,6 +25,59 @@ func (app *PocketBaseClient) CreateProcess(OrganizationID string, eventID string
 	return create.ID, err
 }
 
+func (app *PocketBaseClient) CreateReplayProcess(OrganizationID string, eventID string, triggerID string, replay *model.EventReplay, replayOfID string) (string, error) {
+	create, err := app.pb.Create(
+		"event_processes",
+		map[string]any{
+			"organization": OrganizationID,
+			"event":        eventID,
+			"trigger":      triggerID,
+			"executed":     false,
+			"start_at":     time.Now().Format(time.RFC3339Nano),
+			"replay":       replay.Id,
+			"replay_of":    replayOfID,
+			"dry_run":      replay.DryRun,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return create.ID, err
+}
+
+// GetProcessByEventIdAndTriggerId with an empty replayID only match the original process
+func (app *PocketBaseClient) GetProcessByEventIdAndTriggerId(eventID string, triggerID string, replayID string) (*model.Process, error) {
+	resp, err := pocketbase.CollectionSet[*model.Process](app.pb, "event_processes").List(pocketbase.ParamsList{
+		Page:    0,
+		Size:    1,
+		Filters: fmt.Sprintf("event = \"%s\" && trigger = \"%s\" && replay = \"%s\"", eventID, triggerID, replayID),
+		Sort:    "-created",
+		Expand:  "",
+		Fields:  "",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.TotalItems == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return resp.Items[0], nil
+}
+
+// GetProcess is used to notice a process cancelled by the api while no event
+// service answered for it
+func (app *PocketBaseClient) GetProcess(processID string) (*model.Process, error) {
+	one, err := pocketbase.CollectionSet[model.Process](app.pb, "event_processes").One(processID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &one, nil
+}
+
 func (app *PocketBaseClient) StopProcess(processID string) error {
 	err := app.pb.Update(
 		"event_processes",
@@ -84,3 +141,75 @@ func (app *PocketBaseClient) StopErrorExecutedProcess(processID string, errToSav
 
 	return nil
 }
+
+func (app *PocketBaseClient) StopTimeoutProcess(processID string, errToSave error) error {
+	err := app.pb.Update(
+		"event_processes",
+		processID,
+		map[string]any{
+			"end_at":    time.Now().Format(time.RFC3339Nano),
+			"timed_out": true,
+			"error":     errToSave.Error(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (app *PocketBaseClient) StopTimeoutExecutedProcess(processID string, errToSave error) error {
+	err := app.pb.Update(
+		"event_processes",
+		processID,
+		map[string]any{
+			"end_at":    time.Now().Format(time.RFC3339Nano),
+			"executed":  true,
+			"timed_out": true,
+			"error":     errToSave.Error(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (app *PocketBaseClient) StopCancelledProcess(processID string, userID string, errToSave error) error {
+	err := app.pb.Update(
+		"event_processes",
+		processID,
+		map[string]any{
+			"end_at":       time.Now().Format(time.RFC3339Nano),
+			"cancelled":    true,
+			"cancelled_by": userID,
+			"error":        errToSave.Error(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (app *PocketBaseClient) StopCancelledExecutedProcess(processID string, userID string, errToSave error) error {
+	err := app.pb.Update(
+		"event_processes",
+		processID,
+		map[string]any{
+			"end_at":       time.Now().Format(time.RFC3339Nano),
+			"executed":     true,
+			"cancelled":    true,
+			"cancelled_by": userID,
+			"error":        errToSave.Error(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
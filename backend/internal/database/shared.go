@@ -4,32 +4,18 @@ import (
 	"fmt"
 	"github.com/evntboard/app/backend/internal/model"
 	"github.com/pluja/pocketbase"
-	"strings"
 )
 
-func (app *PocketBaseClient) GetSharedByPath(organizationId string, triggerName string) ([]*model.Shared, error) {
-	validatePath := func(path string) string {
-		keywords := strings.Split(path, "/")
-		if keywords[len(keywords)-1] != "/" {
-			keywords = keywords[:len(keywords)-1]
-		}
-
-		res := make([]string, len(keywords))
-
-		for i := range keywords {
-			wantedKeywords := keywords[:(i + 1)]
-			res[i] = "(name ~ \"" + strings.Join(wantedKeywords, "/") + "/%\" && name !~ \"" + strings.Join(wantedKeywords, "/") + "/%/%\" )"
-		}
-		return "(" + strings.Join(res, " || ") + ")"
-	}
-
+// GetSharedsForOrganization return every shared, the event service resolve
+// the imports of the triggers against them
+func (app *PocketBaseClient) GetSharedsForOrganization(organizationId string) ([]*model.Shared, error) {
 	collection := pocketbase.CollectionSet[*model.Shared](app.pb, "shareds")
 
-	filterStr := fmt.Sprintf("organization = \"%s\" && %s", organizationId, validatePath(triggerName))
+	filterStr := fmt.Sprintf("organization = \"%s\"", organizationId)
 
-	records, err := collection.List(
+	return listAll(
+		collection,
 		pocketbase.ParamsList{
-			Page:    0,
 			Size:    500,
 			Filters: filterStr,
 			Sort:    "-created",
@@ -37,6 +23,4 @@ func (app *PocketBaseClient) GetSharedByPath(organizationId string, triggerName
 			Fields:  "",
 		},
 	)
-
-	return records.Items, err
 }
@@ -6,14 +6,28 @@ import (
 	"github.com/pluja/pocketbase"
 )
 
-func (c *PocketBaseClient) GetConditionsForOrganizationAndEventName(organizationId string, eventName string) ([]*model.TriggerCondition, error) {
+// GetEnabledConditionsForOrganization return every enabled condition, the
+// event service match their names against the events itself
+func (c *PocketBaseClient) GetEnabledConditionsForOrganization(organizationId string) ([]*model.TriggerCondition, error) {
 	collection := pocketbase.CollectionSet[*model.TriggerCondition](c.pb, "trigger_conditions")
-	filterStr := fmt.Sprintf("trigger.organization = \"%s\" && trigger.enable = true && name = \"%s\" && enable = true", organizationId, eventName)
+	filterStr := fmt.Sprintf("trigger.organization = \"%s\" && trigger.enable = true && enable = true", organizationId)
+	return listAll(collection, pocketbase.ParamsList{
+		Size:    500,
+		Filters: filterStr,
+		Sort:    "+created",
+		Expand:  "trigger",
+		Fields:  "",
+	})
+}
+
+func (c *PocketBaseClient) GetConditionsForOrganizationAndTrigger(organizationId string, triggerId string) ([]*model.TriggerCondition, error) {
+	collection := pocketbase.CollectionSet[*model.TriggerCondition](c.pb, "trigger_conditions")
+	filterStr := fmt.Sprintf("trigger.organization = \"%s\" && trigger = \"%s\"", organizationId, triggerId)
 	resp, err := collection.List(pocketbase.ParamsList{
 		Page:    0,
 		Size:    500,
 		Filters: filterStr,
-		Sort:    "",
+		Sort:    "+created",
 		Expand:  "trigger",
 		Fields:  "",
 	})
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/model"
+	"github.com/pluja/pocketbase"
+)
+
+func (c *PocketBaseClient) GetEnabledWebhookByOrganizationIdAndSlug(organizationId, slug string) (*model.Webhook, error) {
+	collection := pocketbase.CollectionSet[model.Webhook](c.pb, "webhooks")
+
+	strFilter := fmt.Sprintf("organization = \"%s\" && slug = \"%s\" && enable = true", organizationId, slug)
+	response, err := collection.List(pocketbase.ParamsList{
+		Size:    1,
+		Page:    0,
+		Sort:    "+created",
+		Filters: strFilter,
+		Expand:  "",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.TotalItems != 1 {
+		return nil, errors.New("not found")
+	}
+
+	return &response.Items[0], nil
+}
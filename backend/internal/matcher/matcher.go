@@ -0,0 +1,286 @@
+package matcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher is either a group (all / any / not) or a single check of the value
+// found at path in the event, eg { "path": "payload.slug", "op": "eq", "value": "btn-1" }
+type Matcher struct {
+	All   []*Matcher `json:"all,omitempty"`
+	Any   []*Matcher `json:"any,omitempty"`
+	Not   *Matcher   `json:"not,omitempty"`
+	Path  string     `json:"path,omitempty"`
+	Op    string     `json:"op,omitempty"`
+	Value any        `json:"value,omitempty"`
+}
+
+const (
+	OpEq         = "eq"
+	OpNeq        = "neq"
+	OpGt         = "gt"
+	OpGte        = "gte"
+	OpLt         = "lt"
+	OpLte        = "lte"
+	OpIn         = "in"
+	OpNotIn      = "nin"
+	OpContains   = "contains"
+	OpStartsWith = "starts_with"
+	OpEndsWith   = "ends_with"
+	OpMatches    = "matches"
+	OpExists     = "exists"
+)
+
+// Parse return nil for an empty matcher, the condition then only use its code
+func Parse(data []byte) (*Matcher, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" || trimmed == "null" || trimmed == "{}" {
+		return nil, nil
+	}
+
+	var m Matcher
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (m *Matcher) Validate() error {
+	groups := 0
+	if m.All != nil {
+		groups++
+	}
+	if m.Any != nil {
+		groups++
+	}
+	if m.Not != nil {
+		groups++
+	}
+
+	if groups > 1 || (groups == 1 && (m.Path != "" || m.Op != "")) {
+		return errors.New("a matcher is either all, any, not or a path check")
+	}
+
+	for _, child := range append(m.All, m.Any...) {
+		if child == nil {
+			return errors.New("empty matcher")
+		}
+		if err := child.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Not != nil {
+		return m.Not.Validate()
+	}
+
+	if groups == 1 {
+		return nil
+	}
+
+	if m.Path == "" {
+		return errors.New("a matcher needs a path")
+	}
+
+	switch m.Op {
+	case OpEq, OpNeq, OpGt, OpGte, OpLt, OpLte, OpContains, OpStartsWith, OpEndsWith, OpExists:
+	case OpIn, OpNotIn:
+		if _, ok := m.Value.([]any); !ok {
+			return fmt.Errorf("%s needs a list as value", m.Op)
+		}
+	case OpMatches:
+		pattern, ok := m.Value.(string)
+		if !ok {
+			return fmt.Errorf("%s needs a regular expression as value", m.Op)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", m.Op)
+	}
+
+	return nil
+}
+
+// Match evaluate the matcher on the event decoded as json (map, slices, float64 ...)
+func (m *Matcher) Match(event map[string]any) bool {
+	switch {
+	case m.All != nil:
+		for _, child := range m.All {
+			if !child.Match(event) {
+				return false
+			}
+		}
+		return true
+
+	case m.Any != nil:
+		for _, child := range m.Any {
+			if child.Match(event) {
+				return true
+			}
+		}
+		return false
+
+	case m.Not != nil:
+		return !m.Not.Match(event)
+	}
+
+	value, found := lookup(event, m.Path)
+
+	switch m.Op {
+	case OpExists:
+		expected, ok := m.Value.(bool)
+		if !ok {
+			expected = true
+		}
+		return found == expected
+	case OpNeq:
+		return !found || !equal(value, m.Value)
+	}
+
+	if !found {
+		return false
+	}
+
+	switch m.Op {
+	case OpEq:
+		return equal(value, m.Value)
+	case OpGt:
+		c, ok := compare(value, m.Value)
+		return ok && c > 0
+	case OpGte:
+		c, ok := compare(value, m.Value)
+		return ok && c >= 0
+	case OpLt:
+		c, ok := compare(value, m.Value)
+		return ok && c < 0
+	case OpLte:
+		c, ok := compare(value, m.Value)
+		return ok && c <= 0
+	case OpIn, OpNotIn:
+		list, _ := m.Value.([]any)
+		in := false
+		for _, item := range list {
+			if equal(value, item) {
+				in = true
+				break
+			}
+		}
+		return in == (m.Op == OpIn)
+	case OpContains:
+		switch v := value.(type) {
+		case string:
+			s, ok := m.Value.(string)
+			return ok && strings.Contains(v, s)
+		case []any:
+			for _, item := range v {
+				if equal(item, m.Value) {
+					return true
+				}
+			}
+		}
+		return false
+	case OpStartsWith:
+		v, ok1 := value.(string)
+		s, ok2 := m.Value.(string)
+		return ok1 && ok2 && strings.HasPrefix(v, s)
+	case OpEndsWith:
+		v, ok1 := value.(string)
+		s, ok2 := m.Value.(string)
+		return ok1 && ok2 && strings.HasSuffix(v, s)
+	case OpMatches:
+		v, ok1 := value.(string)
+		s, ok2 := m.Value.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		matched, err := regexp.MatchString(s, v)
+		return err == nil && matched
+	}
+
+	return false
+}
+
+// lookup follow a dotted path, numeric parts index arrays : payload.items.0.id
+func lookup(value any, path string) (any, bool) {
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			item, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = item
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+func equal(a any, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a any, b any) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	sa, ok1 := a.(string)
+	sb, ok2 := b.(string)
+	if ok1 && ok2 {
+		return strings.Compare(sa, sb), true
+	}
+
+	return 0, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -11,9 +11,18 @@ type Event struct {
 	EmitterCode    string          `json:"emitter_code"`
 	EmitterName    string          `json:"emitter_name"`
 	EmittedAt      string          `json:"emitted_at"`
+	EmittedBy      string          `json:"emitted_by,omitempty"`
+	SchemaErrors   []string        `json:"schema_errors,omitempty"`
+}
+
+type EventReplay struct {
+	Id        string `json:"id"`
+	TriggerId string `json:"trigger"`
+	DryRun    bool   `json:"dry_run"`
 }
 
 type EventReceived struct {
 	Id string `json:"id"`
 	Event
+	Replay *EventReplay `json:"replay,omitempty"`
 }
@@ -0,0 +1,16 @@
+package model
+
+import "encoding/json"
+
+const (
+	EventSchemaModeReject = "REJECT"
+	EventSchemaModeFlag   = "FLAG"
+)
+
+type EventSchema struct {
+	Id             string          `json:"id"`
+	OrganizationId string          `json:"organization"`
+	Name           string          `json:"name"`
+	Schema         json.RawMessage `json:"schema"`
+	Mode           string          `json:"mode"`
+}
@@ -1,20 +1,85 @@
 package model
 
+import "encoding/json"
+
 type Module struct {
-	Id             string       `json:"id"`
-	SessionId      string       `json:"session"`
-	OrganizationId string       `json:"organization"`
-	Sub            string       `json:"sub"`
-	Code           string       `json:"code"`
-	Name           string       `json:"name"`
-	Expand         ModuleExpand `json:"expand"`
+	Id             string          `json:"id"`
+	SessionId      string          `json:"session"`
+	OrganizationId string          `json:"organization"`
+	Sub            string          `json:"sub"`
+	Code           string          `json:"code"`
+	Name           string          `json:"name"`
+	Manifest       *ModuleManifest `json:"manifest"`
+	MaxSessions    int             `json:"max_sessions"`
+	Expand         ModuleExpand    `json:"expand"`
 }
 
 type ModuleExpand struct {
-	Params []ModuleParam `json:"module_params_via_module"`
+	Params    []ModuleParam    `json:"module_params_via_module"`
+	Instances []ModuleInstance `json:"module_sessions_via_module"`
+}
+
+const (
+	ModuleInstanceStatusConnected = "connected"
+	ModuleInstanceStatusDetached  = "detached"
+)
+
+// ModuleInstance is one connected session of a module, a detached one wait for
+// its module to resume
+type ModuleInstance struct {
+	Id       string               `json:"id"`
+	ModuleId string               `json:"module"`
+	Session  string               `json:"session"`
+	Status   string               `json:"status"`
+	Node     string               `json:"node"`
+	TokenId  string               `json:"token"`
+	Expand   ModuleInstanceExpand `json:"expand"`
+}
+
+type ModuleInstanceExpand struct {
+	Module *Module `json:"module"`
+}
+
+// ModuleToken is one of the tokens a module authenticate with, only its hash
+// is stored. No methods or events mean no restriction
+type ModuleToken struct {
+	Id        string            `json:"id"`
+	ModuleId  string            `json:"module"`
+	Label     string            `json:"label"`
+	Prefix    string            `json:"prefix"`
+	Methods   []string          `json:"methods"`
+	Events    []string          `json:"events"`
+	ExpiresAt string            `json:"expires_at"`
+	RevokedAt string            `json:"revoked_at"`
+	Expand    ModuleTokenExpand `json:"expand"`
+}
+
+type ModuleTokenExpand struct {
+	Module *Module `json:"module"`
 }
 
 type ModuleParam struct {
 	Key   string `json:"key"`
 	Value any    `json:"value"`
 }
+
+// ModuleManifest is what a module declare at session.register, the schemas
+// are json schemas
+type ModuleManifest struct {
+	Version string                 `json:"version"`
+	Methods []ModuleManifestMethod `json:"methods"`
+	Events  []ModuleManifestEvent  `json:"events"`
+}
+
+type ModuleManifestMethod struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+}
+
+type ModuleManifestEvent struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+}
@@ -0,0 +1,7 @@
+package model
+
+type Organization struct {
+	Id               string   `json:"id"`
+	Name             string   `json:"name"`
+	HttpAllowedHosts []string `json:"http_allowed_hosts"`
+}
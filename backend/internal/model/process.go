@@ -0,0 +1,17 @@
+package model
+
+type Process struct {
+	Id          string `json:"id"`
+	EventId     string `json:"event"`
+	TriggerId   string `json:"trigger"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at"`
+	Error       string `json:"error"`
+	Executed    bool   `json:"executed"`
+	TimedOut    bool   `json:"timed_out"`
+	Cancelled   bool   `json:"cancelled"`
+	CancelledBy string `json:"cancelled_by"`
+	Replay      string `json:"replay"`
+	ReplayOf    string `json:"replay_of"`
+	DryRun      bool   `json:"dry_run"`
+}
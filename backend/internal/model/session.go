@@ -1,10 +1,7 @@
 package model
 
-import (
-	"github.com/nats-io/nats.go"
-)
-
 type ModuleSession struct {
-	Module       *Module
-	Subscription *nats.Subscription
+	Module      *Module
+	Token       *ModuleToken
+	ResumeToken string
 }
@@ -5,4 +5,9 @@ type Shared struct {
 	Id             string `json:"id"`
 	Name           string `json:"name"`
 	Code           string `json:"code"`
+	Language       string `json:"language"`
+	CompiledCode   string `json:"compiled_code"`
+	Enable         bool   `json:"enable"`
+	Created        string `json:"created"`
+	Updated        string `json:"updated"`
 }
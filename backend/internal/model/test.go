@@ -0,0 +1,30 @@
+package model
+
+type TriggerTestRequest struct {
+	OrganizationId string `json:"organization"`
+	TriggerId      string `json:"trigger"`
+	ConditionId    string `json:"condition"`
+	Event          Event  `json:"event"`
+}
+
+type TriggerTestCall struct {
+	Type   string `json:"type"`
+	Module string `json:"module,omitempty"`
+	Method string `json:"method,omitempty"`
+	Params any    `json:"params"`
+}
+
+type TriggerTestResult struct {
+	ConditionId   string            `json:"condition"`
+	ConditionName string            `json:"condition_name"`
+	Result        bool              `json:"result"`
+	Executed      bool              `json:"executed"`
+	Error         string            `json:"error,omitempty"`
+	Logs          []any             `json:"logs"`
+	Calls         []TriggerTestCall `json:"calls"`
+}
+
+type TriggerTestResponse struct {
+	Results []TriggerTestResult `json:"results"`
+	Error   string              `json:"error,omitempty"`
+}
@@ -1,12 +1,18 @@
 package model
 
+import "encoding/json"
+
 type Trigger struct {
-	Id             string `json:"id"`
-	OrganizationId string `json:"organization"`
-	Code           string `json:"code"`
-	Name           string `json:"name"`
-	Enable         bool   `json:"enable"`
-	Channel        string `json:"channel"`
+	Id               string `json:"id"`
+	OrganizationId   string `json:"organization"`
+	Code             string `json:"code"`
+	Language         string `json:"language"`
+	CompiledCode     string `json:"compiled_code"`
+	Name             string `json:"name"`
+	Enable           bool   `json:"enable"`
+	Channel          string `json:"channel"`
+	MaxExecutionTime int    `json:"max_execution_time"`
+	Updated          string `json:"updated"`
 }
 
 type TriggerConditionExpand struct {
@@ -14,11 +20,16 @@ type TriggerConditionExpand struct {
 }
 
 type TriggerCondition struct {
-	Id      string                 `json:"id"`
-	Code    string                 `json:"code"`
-	Name    string                 `json:"name"`
-	Enable  bool                   `json:"enable"`
-	Type    string                 `json:"type"`
-	Timeout int                    `json:"timeout"`
-	Expand  TriggerConditionExpand `json:"expand"`
+	Id               string                 `json:"id"`
+	Code             string                 `json:"code"`
+	Language         string                 `json:"language"`
+	CompiledCode     string                 `json:"compiled_code"`
+	Name             string                 `json:"name"`
+	Enable           bool                   `json:"enable"`
+	Type             string                 `json:"type"`
+	Timeout          int                    `json:"timeout"`
+	MaxExecutionTime int                    `json:"max_execution_time"`
+	Matcher          json.RawMessage        `json:"matcher"`
+	Updated          string                 `json:"updated"`
+	Expand           TriggerConditionExpand `json:"expand"`
 }
@@ -0,0 +1,17 @@
+package model
+
+type Webhook struct {
+	Id                      string `json:"id"`
+	OrganizationId          string `json:"organization"`
+	Slug                    string `json:"slug"`
+	EventName               string `json:"event_name"`
+	Enable                  bool   `json:"enable"`
+	Secret                  string `json:"secret"`
+	PreviousSecret          string `json:"previous_secret"`
+	PreviousSecretExpiresAt string `json:"previous_secret_expires_at"`
+	SignatureScheme         string `json:"signature_scheme"`
+	SignatureHeader         string `json:"signature_header"`
+	SignaturePrefix         string `json:"signature_prefix"`
+	SignatureAlgorithm      string `json:"signature_algorithm"`
+	SignatureEncoding       string `json:"signature_encoding"`
+}
@@ -0,0 +1,93 @@
+package moduletoken
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/evntboard/app/backend/internal/pattern"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"slices"
+	"time"
+)
+
+const (
+	MethodEventNew   = "event.new"
+	MethodStorageGet = "storage.get"
+	MethodStorageSet = "storage.set"
+
+	tokenLength  = 50
+	prefixLength = 6
+)
+
+// Methods are the rpc methods a token can be restricted to, the others
+// (session.*) are always allowed
+var Methods = []string{MethodEventNew, MethodStorageGet, MethodStorageSet}
+
+// Generate return a new token, only its hash is stored and the prefix help to
+// recognize it
+func Generate() (token string, prefix string, hash string) {
+	token = security.RandomString(tokenLength)
+	return token, token[:prefixLength], Hash(token)
+}
+
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes check the methods and the event patterns of a token
+func ValidateScopes(methods []string, events []string) error {
+	for _, method := range methods {
+		if !slices.Contains(Methods, method) {
+			return fmt.Errorf("unknown method %s", method)
+		}
+	}
+
+	for _, event := range events {
+		if _, err := pattern.Parse(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AllowMethod is true for a method in the scope, no methods allow them all
+func AllowMethod(methods []string, method string) bool {
+	if len(methods) == 0 || !slices.Contains(Methods, method) {
+		return true
+	}
+	return slices.Contains(methods, method)
+}
+
+// AllowEvent is true for an event name matching one of the patterns, no
+// patterns allow every name
+func AllowEvent(events []string, name string) bool {
+	if len(events) == 0 {
+		return true
+	}
+
+	for _, event := range events {
+		p, err := pattern.Parse(event)
+		if err == nil && p.Match(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired is true once the expiry date is passed, an empty date never expire
+func Expired(expiresAt string, now time.Time) bool {
+	if expiresAt == "" {
+		return false
+	}
+
+	expires, err := types.ParseDateTime(expiresAt)
+	if err != nil || expires.IsZero() {
+		return true
+	}
+
+	return !now.Before(expires.Time())
+}
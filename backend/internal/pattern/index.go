@@ -0,0 +1,96 @@
+package pattern
+
+import "strings"
+
+// Index find the values of every pattern matching an event name : exact
+// names are a map lookup, wildcards walk a tree of tokens and only the
+// regex are tried one by one
+type Index[T any] struct {
+	exact    map[string][]T
+	wildcard *node[T]
+	regex    []regexEntry[T]
+}
+
+type node[T any] struct {
+	children map[string]*node[T]
+	values   []T
+	rest     []T
+}
+
+type regexEntry[T any] struct {
+	pattern *Pattern
+	value   T
+}
+
+func NewIndex[T any]() *Index[T] {
+	return &Index[T]{
+		exact:    make(map[string][]T),
+		wildcard: newNode[T](),
+	}
+}
+
+func newNode[T any]() *node[T] {
+	return &node[T]{children: make(map[string]*node[T])}
+}
+
+func (idx *Index[T]) Add(p *Pattern, value T) {
+	switch {
+	case p.regex != nil:
+		idx.regex = append(idx.regex, regexEntry[T]{pattern: p, value: value})
+
+	case p.IsExact():
+		idx.exact[p.raw] = append(idx.exact[p.raw], value)
+
+	default:
+		current := idx.wildcard
+		for _, token := range p.tokens {
+			if token == TokenRest {
+				current.rest = append(current.rest, value)
+				return
+			}
+
+			next, ok := current.children[token]
+			if !ok {
+				next = newNode[T]()
+				current.children[token] = next
+			}
+			current = next
+		}
+		current.values = append(current.values, value)
+	}
+}
+
+// Match return the values in the order exact, wildcard then regex
+func (idx *Index[T]) Match(name string) []T {
+	var values []T
+
+	values = append(values, idx.exact[name]...)
+	values = idx.wildcard.match(strings.Split(name, Separator), values)
+
+	for _, entry := range idx.regex {
+		if entry.pattern.Match(name) {
+			values = append(values, entry.value)
+		}
+	}
+
+	return values
+}
+
+func (n *node[T]) match(tokens []string, values []T) []T {
+	if len(tokens) == 0 {
+		return append(values, n.values...)
+	}
+
+	// > need at least one token left
+	values = append(values, n.rest...)
+
+	if next, ok := n.children[tokens[0]]; ok {
+		values = next.match(tokens[1:], values)
+	}
+
+	if next, ok := n.children[TokenAny]; ok {
+		values = next.match(tokens[1:], values)
+	}
+
+	return values
+}
@@ -0,0 +1,105 @@
+package pattern
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Separator split an event name in tokens : twitch.channel.follow
+const Separator = "."
+
+const (
+	// TokenAny match exactly one token : twitch.*
+	TokenAny = "*"
+	// TokenRest match one or more tokens, only as the last one : board.>
+	TokenRest = ">"
+)
+
+// Pattern is the name of a condition, either an exact event name, a
+// wildcard such as twitch.* / board.button.> or a regex between slashes
+type Pattern struct {
+	raw    string
+	tokens []string
+	regex  *regexp.Regexp
+}
+
+func Parse(raw string) (*Pattern, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("empty event name")
+	}
+
+	p := &Pattern{raw: raw}
+
+	if len(raw) > 2 && strings.HasPrefix(raw, "/") && strings.HasSuffix(raw, "/") {
+		regex, err := regexp.Compile(raw[1 : len(raw)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid event name regex : %s", err.Error())
+		}
+		p.regex = regex
+		return p, nil
+	}
+
+	p.tokens = strings.Split(raw, Separator)
+
+	for i, token := range p.tokens {
+		switch {
+		case token == "":
+			return nil, fmt.Errorf("empty token in event name %q", raw)
+		case token == TokenRest && i != len(p.tokens)-1:
+			return nil, fmt.Errorf("%s must be the last token of %q", TokenRest, raw)
+		case token != TokenAny && token != TokenRest && strings.ContainsAny(token, TokenAny+TokenRest):
+			return nil, fmt.Errorf("%s and %s must be a whole token in %q", TokenAny, TokenRest, raw)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// IsExact is true when the pattern only match the event with the same name
+func (p *Pattern) IsExact() bool {
+	if p.regex != nil {
+		return false
+	}
+
+	for _, token := range p.tokens {
+		if token == TokenAny || token == TokenRest {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Pattern) Match(name string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(name)
+	}
+
+	return matchTokens(p.tokens, strings.Split(name, Separator))
+}
+
+func matchTokens(pattern []string, tokens []string) bool {
+	for i, token := range pattern {
+		if token == TokenRest {
+			return len(tokens) > i
+		}
+		if i >= len(tokens) || (token != TokenAny && token != tokens[i]) {
+			return false
+		}
+	}
+
+	return len(pattern) == len(tokens)
+}
+
+// IsExactName is a shortcut for names that must be listed as real event names
+func IsExactName(raw string) bool {
+	p, err := Parse(raw)
+	return err == nil && p.IsExact()
+}
@@ -0,0 +1,18 @@
+package realtime
+
+import "fmt"
+
+// the event service cache the triggers, conditions and shareds of an
+// organization until a message on its channel tell it they changed
+
+func (c *Client) GetChannelForOrganizationCache(organizationId string) string {
+	return fmt.Sprintf("cache.%s", organizationId)
+}
+
+func (c *Client) GetChannelForAllOrganizationCaches() string {
+	return "cache.*"
+}
+
+func (c *Client) PublishCacheInvalidation(organizationId string) error {
+	return c.Publish(c.GetChannelForOrganizationCache(organizationId), []byte(organizationId))
+}
@@ -1,7 +1,60 @@
 package realtime
 
-import "github.com/evntboard/app/backend/internal/model"
+import (
+	"errors"
+	"github.com/nats-io/nats.go"
+	"time"
+)
 
-func (c Client) PublishEvent(event *model.Event) {
+const (
+	EventStreamName   = "EVENTS"
+	EventSubject      = "events"
+	EventStreamMaxAge = 24 * time.Hour
+	EventAckWait      = 30 * time.Second
+	EventMaxDeliver   = 10
+	EventNakDelay     = 5 * time.Second
+)
 
+func (c *Client) EnsureEventStream() error {
+	_, err := c.js.StreamInfo(EventStreamName)
+
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return err
+	}
+
+	_, err = c.js.AddStream(&nats.StreamConfig{
+		Name:      EventStreamName,
+		Subjects:  []string{EventSubject},
+		Retention: nats.LimitsPolicy,
+		Storage:   nats.FileStorage,
+		MaxAge:    EventStreamMaxAge,
+	})
+
+	return err
+}
+
+func (c *Client) PublishEvent(eventId string, data []byte) error {
+	// event id as msg id : JetStream drop duplicates if the api retry a publish
+	_, err := c.js.Publish(EventSubject, data, nats.MsgId(eventId))
+	return err
+}
+
+func (c *Client) SubscribeEvents(queue string, handler nats.MsgHandler) (*nats.Subscription, error) {
+	// every replica share the same durable consumer, each event is delivered to one of them
+	return c.js.QueueSubscribe(
+		EventSubject,
+		queue,
+		handler,
+		nats.BindStream(EventStreamName),
+		nats.Durable(queue),
+		nats.ManualAck(),
+		nats.AckExplicit(),
+		nats.AckWait(EventAckWait),
+		nats.MaxDeliver(EventMaxDeliver),
+		nats.DeliverAll(),
+	)
 }
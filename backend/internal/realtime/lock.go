@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"github.com/nats-io/nats.go"
+	"sync"
+	"time"
+)
+
+// ErrLockLost is the cause given to the holder when the lease can't be
+// refreshed, another owner may already hold the lock
+var ErrLockLost = errors.New("lock lost")
+
+type Lock struct {
+	kv       nats.KeyValue
+	key      string
+	revision uint64
+	mu       sync.Mutex
+	done     chan struct{}
+	lost     chan struct{}
+}
+
+// AcquireLock blocks until the lock is owned, the lease is refreshed while
+// owned so a crashed owner only blocks others for LockTTL.
+func (c *Client) AcquireLock(ctx context.Context, key string) (*Lock, error) {
+	for {
+		revision, err := c.locks.Create(key, []byte(time.Now().Format(time.RFC3339Nano)))
+
+		if err == nil {
+			lock := &Lock{
+				kv:       c.locks,
+				key:      key,
+				revision: revision,
+				done:     make(chan struct{}),
+				lost:     make(chan struct{}),
+			}
+			go lock.refresh()
+			return lock, nil
+		}
+
+		if !errors.Is(err, nats.ErrKeyExists) {
+			return nil, err
+		}
+
+		if err := c.waitLockRelease(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) waitLockRelease(ctx context.Context, key string) error {
+	watcher, err := c.locks.Watch(key)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	// an expired lease is removed without notification
+	timer := time.NewTimer(LockTTL)
+	defer timer.Stop()
+
+	seen := false
+	for {
+		select {
+		case entry := <-watcher.Updates():
+			if entry == nil {
+				if !seen {
+					return nil
+				}
+				continue
+			}
+			seen = true
+			if entry.Operation() != nats.KeyValuePut {
+				return nil
+			}
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+func (l *Lock) refresh() {
+	ticker := time.NewTicker(LockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			revision, err := l.kv.Update(l.key, []byte(time.Now().Format(time.RFC3339Nano)), l.revision)
+			if err == nil {
+				l.revision = revision
+			}
+			l.mu.Unlock()
+
+			// the lease may expire before the next try, stop now rather than
+			// run next to a new owner
+			if err != nil {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Lost is closed once the lease couldn't be refreshed, the holder must stop
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lock) Release() error {
+	close(l.done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.kv.Delete(l.key, nats.LastRevision(l.revision))
+}
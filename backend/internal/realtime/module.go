@@ -7,3 +7,9 @@ import (
 func (c *Client) GetChannelForModule(moduleSession string) string {
 	return fmt.Sprintf("module.%s", moduleSession)
 }
+
+// GetChannelForModuleControl is answered by the replica that hold the session,
+// whatever the replica the module reach
+func (c *Client) GetChannelForModuleControl(moduleSession string) string {
+	return fmt.Sprintf("module.%s.control", moduleSession)
+}
@@ -7,6 +7,9 @@ import (
 
 type Client struct {
 	*nats.Conn
+	js     nats.JetStreamContext
+	states nats.KeyValue
+	locks  nats.KeyValue
 }
 
 func NewRealtimeClient(natsUrl string) *Client {
@@ -16,7 +19,14 @@ func NewRealtimeClient(natsUrl string) *Client {
 		log.Fatal(err)
 	}
 
+	js, err := nc.JetStream()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return &Client{
-		nc,
+		Conn: nc,
+		js:   js,
 	}
 }
@@ -0,0 +1,13 @@
+package realtime
+
+import (
+	"fmt"
+)
+
+func (c *Client) GetChannelForProcess(processId string) string {
+	return fmt.Sprintf("process.%s", processId)
+}
+
+func (c *Client) GetChannelForAllProcesses() string {
+	return "process.*"
+}
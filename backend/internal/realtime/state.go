@@ -0,0 +1,212 @@
+package realtime
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"time"
+)
+
+const (
+	StateBucketName  = "event_states"
+	StateBucketTTL   = 24 * time.Hour
+	LockBucketName   = "event_locks"
+	LockTTL          = 30 * time.Second
+	stateMaxAttempts = 10
+)
+
+func (c *Client) EnsureEventStateBuckets() error {
+	states, err := c.ensureKeyValue(&nats.KeyValueConfig{
+		Bucket:  StateBucketName,
+		TTL:     StateBucketTTL,
+		Storage: nats.FileStorage,
+	})
+	if err != nil {
+		return err
+	}
+
+	locks, err := c.ensureKeyValue(&nats.KeyValueConfig{
+		Bucket:  LockBucketName,
+		TTL:     LockTTL,
+		Storage: nats.MemoryStorage,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.states = states
+	c.locks = locks
+
+	return nil
+}
+
+func (c *Client) ensureKeyValue(config *nats.KeyValueConfig) (nats.KeyValue, error) {
+	kv, err := c.js.KeyValue(config.Bucket)
+
+	if err == nil {
+		return kv, nil
+	}
+
+	if !errors.Is(err, nats.ErrBucketNotFound) {
+		return nil, err
+	}
+
+	return c.js.CreateKeyValue(config)
+}
+
+func (c *Client) GetKeyForCondition(triggerId string, conditionId string) string {
+	return fmt.Sprintf("%s.%s", triggerId, conditionId)
+}
+
+func (c *Client) GetKeyForChannel(organizationId string, channel string) string {
+	// channel is free text, kv keys only allow a small charset
+	return fmt.Sprintf("channel.%s.%s", organizationId, base64.RawURLEncoding.EncodeToString([]byte(channel)))
+}
+
+type throttleState struct {
+	LastExecution time.Time `json:"last_execution"`
+	Pending       string    `json:"pending"`
+}
+
+type Throttle struct {
+	kv      nats.KeyValue
+	key     string
+	timeout time.Duration
+}
+
+func (c *Client) NewThrottle(key string, timeout time.Duration) *Throttle {
+	return &Throttle{
+		kv:      c.states,
+		key:     "throttle." + key,
+		timeout: timeout,
+	}
+}
+
+func (t *Throttle) get() (*throttleState, uint64, error) {
+	entry, err := t.kv.Get(t.key)
+
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return &throttleState{}, 0, nil
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var state throttleState
+	if err := json.Unmarshal(entry.Value(), &state); err != nil {
+		return nil, 0, err
+	}
+
+	return &state, entry.Revision(), nil
+}
+
+func (t *Throttle) set(state *throttleState, revision uint64) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if revision == 0 {
+		_, err = t.kv.Create(t.key, data)
+	} else {
+		_, err = t.kv.Update(t.key, data, revision)
+	}
+
+	return err
+}
+
+// ScheduleAction runs action now if the throttle window is over, otherwise it
+// becomes the pending action of the window. A pending action replaced by another
+// one, on any replica, gets its cancel called instead.
+func (t *Throttle) ScheduleAction(id string, action func(), cancel func()) error {
+	for attempt := 0; attempt < stateMaxAttempts; attempt++ {
+		state, revision, err := t.get()
+		if err != nil {
+			return err
+		}
+
+		elapsed := time.Since(state.LastExecution)
+
+		if elapsed >= t.timeout {
+			if err := t.set(&throttleState{LastExecution: time.Now()}, revision); err != nil {
+				continue
+			}
+			action()
+			return nil
+		}
+
+		if err := t.set(&throttleState{LastExecution: state.LastExecution, Pending: id}, revision); err != nil {
+			continue
+		}
+
+		time.AfterFunc(t.timeout-elapsed, func() {
+			t.runPending(id, action, cancel)
+		})
+		return nil
+	}
+
+	return fmt.Errorf("throttle %s : too many concurrent updates", t.key)
+}
+
+func (t *Throttle) runPending(id string, action func(), cancel func()) {
+	for attempt := 0; attempt < stateMaxAttempts; attempt++ {
+		state, revision, err := t.get()
+		if err != nil {
+			break
+		}
+
+		if state.Pending != id {
+			break
+		}
+
+		if err := t.set(&throttleState{LastExecution: time.Now()}, revision); err != nil {
+			continue
+		}
+
+		action()
+		return
+	}
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+type Debounce struct {
+	kv      nats.KeyValue
+	key     string
+	timeout time.Duration
+}
+
+func (c *Client) NewDebounce(key string, timeout time.Duration) *Debounce {
+	return &Debounce{
+		kv:      c.states,
+		key:     "debounce." + key,
+		timeout: timeout,
+	}
+}
+
+// ScheduleAction runs action after the timeout unless another action is
+// scheduled on the same key in the meantime, in that case cancel is called.
+func (d *Debounce) ScheduleAction(id string, action func(), cancel func()) error {
+	revision, err := d.kv.Put(d.key, []byte(id))
+	if err != nil {
+		return err
+	}
+
+	time.AfterFunc(d.timeout, func() {
+		// the delete only succeed if nobody scheduled an action after us
+		if err := d.kv.Delete(d.key, nats.LastRevision(revision)); err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			return
+		}
+		action()
+	})
+
+	return nil
+}
@@ -0,0 +1,5 @@
+package realtime
+
+func (c *Client) GetChannelForTriggerTest() string {
+	return "trigger.test"
+}
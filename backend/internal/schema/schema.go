@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+)
+
+// schemaURL is not a file path, the errors don't show where the service run
+const schemaURL = "mem://schema.json"
+
+// Compile check that a json schema is valid, the schemas come from the users
+// so a $ref can't load anything outside of it (file, http)
+func Compile(schema json.RawMessage) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external $ref not allowed : %s", url)
+	}
+
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(schema)); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(schemaURL)
+}
+
+// Validate return what doesn't match the schema in the payload, the error is
+// for an invalid schema
+func Validate(schema json.RawMessage, payload json.RawMessage) ([]string, error) {
+	compiled, err := Compile(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	err = compiled.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationError *jsonschema.ValidationError
+	if !errors.As(err, &validationError) {
+		return nil, err
+	}
+
+	var schemaErrors []string
+	for _, unit := range validationError.BasicOutput().Errors {
+		// the root unit only says that something below is invalid
+		if unit.KeywordLocation == "" {
+			continue
+		}
+
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		schemaErrors = append(schemaErrors, fmt.Sprintf("%s %s", location, unit.Error))
+	}
+
+	return schemaErrors, nil
+}
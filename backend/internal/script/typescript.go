@@ -0,0 +1,49 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"github.com/evanw/esbuild/pkg/api"
+	"strings"
+)
+
+const (
+	LanguageJavascript = "javascript"
+	LanguageTypescript = "typescript"
+)
+
+// Transpile strip the types of a typescript code, imports and exports are
+// kept for the event service to resolve the shareds. An import only used as a
+// type is kept as well (verbatimModuleSyntax), a shared imported for its side
+// effects still run : a type must be imported with "import type"
+func Transpile(name string, code string) (string, error) {
+	result := api.Transform(code, api.TransformOptions{
+		Loader:      api.LoaderTS,
+		Sourcefile:  name,
+		Target:      api.ESNext,
+		TsconfigRaw: `{"compilerOptions": {"verbatimModuleSyntax": true}}`,
+		LogLevel:    api.LogLevelSilent,
+	})
+
+	if len(result.Errors) > 0 {
+		lines := make([]string, 0, len(result.Errors))
+		for _, message := range result.Errors {
+			if message.Location == nil {
+				lines = append(lines, message.Text)
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", message.Location.File, message.Location.Line, message.Location.Column+1, message.Text))
+		}
+		return "", errors.New(strings.Join(lines, "\n"))
+	}
+
+	return string(result.Code), nil
+}
+
+// Code is what the event service run : the transpiled code for typescript
+func Code(language string, code string, compiledCode string) string {
+	if language == LanguageTypescript {
+		return compiledCode
+	}
+	return code
+}
@@ -0,0 +1,234 @@
+package typings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxDepth stop nested or recursive schemas, deeper values are any
+const maxDepth = 8
+
+// FromJsonSchema turn a json schema into a typescript type, what can't be
+// expressed is any
+func FromJsonSchema(data []byte) string {
+	var schema any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return "any"
+	}
+	return fromSchema(schema, 0)
+}
+
+func fromSchema(value any, depth int) string {
+	schema, ok := value.(map[string]any)
+	if !ok || depth > maxDepth {
+		// true or an empty schema accept anything
+		return "any"
+	}
+
+	if constant, ok := schema["const"]; ok {
+		return literal(constant)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, item := range enum {
+			values = append(values, literal(item))
+		}
+		return union(values)
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[key].([]any); ok && len(options) > 0 {
+			values := make([]string, 0, len(options))
+			for _, option := range options {
+				values = append(values, fromSchema(option, depth+1))
+			}
+			return union(values)
+		}
+	}
+
+	if all, ok := schema["allOf"].([]any); ok && len(all) > 0 {
+		values := make([]string, 0, len(all))
+		for _, item := range all {
+			values = append(values, wrap(fromSchema(item, depth+1)))
+		}
+		return strings.Join(values, " & ")
+	}
+
+	switch t := schema["type"].(type) {
+	case string:
+		return fromSchemaType(t, schema, depth)
+	case []any:
+		values := make([]string, 0, len(t))
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				values = append(values, fromSchemaType(name, schema, depth))
+			}
+		}
+		return union(values)
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return fromSchemaType("object", schema, depth)
+	}
+
+	return "any"
+}
+
+func fromSchemaType(name string, schema map[string]any, depth int) string {
+	switch name {
+	case "string":
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		if items, ok := schema["items"]; ok {
+			return wrap(fromSchema(items, depth+1)) + "[]"
+		}
+		return "any[]"
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+
+		required := make(map[string]bool)
+		if list, ok := schema["required"].([]any); ok {
+			for _, item := range list {
+				if key, ok := item.(string); ok {
+					required[key] = true
+				}
+			}
+		}
+
+		fields := make([]string, 0, len(properties)+1)
+		for _, key := range sortedKeys(properties) {
+			optional := "?"
+			if required[key] {
+				optional = ""
+			}
+			field := fmt.Sprintf("%s%s: %s;", propertyName(key), optional, indent(fromSchema(properties[key], depth+1), 1))
+			if description, ok := properties[key].(map[string]any)["description"].(string); ok && description != "" {
+				field = fmt.Sprintf("/** %s */\n\t%s", strings.ReplaceAll(description, "*/", "* /"), field)
+			}
+			fields = append(fields, field)
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if additional {
+				fields = append(fields, "[key: string]: any;")
+			}
+		case map[string]any:
+			fields = append(fields, fmt.Sprintf("[key: string]: %s;", indent(fromSchema(additional, depth+1), 1)))
+		case nil:
+			if len(properties) == 0 {
+				return "Record<string, any>"
+			}
+		}
+
+		return object(fields)
+	}
+
+	return "any"
+}
+
+// FromValue infer a typescript type from a recorded payload
+func FromValue(data []byte) string {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "any"
+	}
+	return fromValue(value, 0)
+}
+
+func fromValue(value any, depth int) string {
+	if depth > maxDepth {
+		return "any"
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		if len(v) == 0 {
+			return "any[]"
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fromValue(item, depth+1))
+		}
+		return wrap(union(values)) + "[]"
+	case map[string]any:
+		fields := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			fields = append(fields, fmt.Sprintf("%s: %s;", propertyName(key), indent(fromValue(v[key], depth+1), 1)))
+		}
+		if len(fields) == 0 {
+			return "Record<string, any>"
+		}
+		return object(fields)
+	}
+
+	return "any"
+}
+
+func literal(value any) string {
+	switch value.(type) {
+	case string, float64, bool, nil:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return "any"
+}
+
+// union drop the duplicates, any absorb every other type
+func union(values []string) string {
+	var unique []string
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		if value == "any" {
+			return "any"
+		}
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	if len(unique) == 0 {
+		return "any"
+	}
+
+	return strings.Join(unique, " | ")
+}
+
+func wrap(value string) string {
+	if strings.Contains(value, " | ") || strings.Contains(value, " & ") {
+		return "(" + value + ")"
+	}
+	return value
+}
+
+func object(fields []string) string {
+	return "{\n\t" + strings.Join(fields, "\n\t") + "\n}"
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package typings
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Event is an event name with the type of its payload in typescript
+type Event struct {
+	Name        string
+	Description string
+	Payload     string
+}
+
+// Module is a module callable from the triggers, by its name or its code. An
+// open module accept more methods than the known ones
+type Module struct {
+	Name    string
+	Code    string
+	Methods []Method
+	Open    bool
+}
+
+// Method is a module method with its params and result in typescript
+type Method struct {
+	Name        string
+	Description string
+	Params      string
+	Result      string
+}
+
+type Definitions struct {
+	Events  []Event
+	Modules []Module
+}
+
+const globals = `interface EvntboardHttpRequest {
+	url: string;
+	method?: string;
+	headers?: Record<string, string>;
+	body?: string | object;
+}
+
+interface EvntboardHttpResponse {
+	url: string;
+	status: number;
+	ok: boolean;
+	headers: Record<string, string>;
+	body: string;
+	text(): string;
+	json<T = any>(): T;
+}
+
+/** the event that started the process */
+declare const event: EvntboardEvent;
+
+declare const module: {
+	/** wait for the module answer, or return a Promise with { async: true } */
+	request<M extends EvntboardModuleName, K extends EvntboardModuleMethod<M>>(module: M, method: K, params?: EvntboardModuleCall<M, K>["params"], options?: { async?: false }): EvntboardModuleCall<M, K>["result"];
+	request<M extends EvntboardModuleName, K extends EvntboardModuleMethod<M>>(module: M, method: K, params: EvntboardModuleCall<M, K>["params"], options: { async: true }): Promise<EvntboardModuleCall<M, K>["result"]>;
+	/** send without waiting to one instance of the module, or to all with { broadcast: true } */
+	notify<M extends EvntboardModuleName, K extends EvntboardModuleMethod<M>>(module: M, method: K, params?: EvntboardModuleCall<M, K>["params"], options?: { broadcast?: boolean }): void;
+};
+
+declare const storage: {
+	get<T = any>(key: string): T | null;
+	set<T>(key: string, value: T): T;
+};
+
+declare const http: {
+	/** block until the response, the host must be allowed for the organization */
+	request(options: EvntboardHttpRequest): EvntboardHttpResponse;
+};
+
+declare function fetch(url: string, options?: Omit<EvntboardHttpRequest, "url">): Promise<EvntboardHttpResponse>;
+
+declare function log(...data: unknown[]): void;
+
+/** @deprecated block the whole script, use setTimeout */
+declare function sleep(ms: number): void;
+
+declare function setTimeout(callback: (...args: any[]) => void, ms?: number, ...args: any[]): number;
+declare function setInterval(callback: (...args: any[]) => void, ms?: number, ...args: any[]): number;
+declare function clearTimeout(id: number): void;
+declare function clearInterval(id: number): void;
+`
+
+// Render write the .d.ts of an organization, the event is a union
+// discriminated by its name : if (event.name === "...") type the payload
+func (d *Definitions) Render() string {
+	var b strings.Builder
+
+	b.WriteString("// generated by evntboard, do not edit\n\n")
+
+	b.WriteString("interface EvntboardEventBase<N extends string, P> {\n")
+	b.WriteString("\tid: string;\n")
+	b.WriteString("\tname: N;\n")
+	b.WriteString("\tpayload: P;\n")
+	b.WriteString("\temitted_at: string;\n")
+	b.WriteString("\temitter_code: string;\n")
+	b.WriteString("\temitter_name: string;\n")
+	b.WriteString("\temitted_by?: string;\n")
+	b.WriteString("\tschema_errors?: string[];\n")
+	b.WriteString("}\n\n")
+
+	events := append([]Event(nil), d.Events...)
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+
+	b.WriteString("interface EvntboardEvents {\n")
+	for _, e := range events {
+		if e.Description != "" {
+			fmt.Fprintf(&b, "\t/** %s */\n", strings.ReplaceAll(e.Description, "*/", "* /"))
+		}
+		fmt.Fprintf(&b, "\t%s: %s;\n", quote(e.Name), indent(e.Payload, 1))
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("type EvntboardEvent = keyof EvntboardEvents extends never\n")
+	b.WriteString("\t? EvntboardEventBase<string, any>\n")
+	b.WriteString("\t: { [N in keyof EvntboardEvents]: EvntboardEventBase<N & string, EvntboardEvents[N]> }[keyof EvntboardEvents];\n\n")
+
+	modules := append([]Module(nil), d.Modules...)
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+
+	b.WriteString("interface EvntboardModules {\n")
+	seen := make(map[string]bool)
+	var open []string
+	for _, m := range modules {
+		methods := append([]Method(nil), m.Methods...)
+		sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+
+		fields := make([]string, 0, len(methods))
+		for _, method := range methods {
+			field := fmt.Sprintf("%s: { params: %s; result: %s };", quote(method.Name), indent(orAny(method.Params), 1), indent(orAny(method.Result), 1))
+			if method.Description != "" {
+				field = fmt.Sprintf("/** %s */\n\t%s", strings.ReplaceAll(method.Description, "*/", "* /"), field)
+			}
+			fields = append(fields, field)
+		}
+
+		methodsType := "{}"
+		if len(fields) > 0 {
+			methodsType = object(fields)
+		}
+
+		for _, key := range []string{m.Name, m.Code} {
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			fmt.Fprintf(&b, "\t%s: %s;\n", quote(key), indent(methodsType, 1))
+			if m.Open {
+				open = append(open, quote(key))
+			}
+		}
+	}
+	b.WriteString("}\n\n")
+
+	// other methods stay allowed on a module without manifest, it may have more than what was seen
+	openModules := "never"
+	if len(open) > 0 {
+		openModules = strings.Join(open, " | ")
+	}
+	fmt.Fprintf(&b, "type EvntboardOpenModule = %s;\n\n", openModules)
+
+	b.WriteString("type EvntboardModuleName = keyof EvntboardModules extends never ? string : keyof EvntboardModules & string;\n")
+	b.WriteString("type EvntboardModuleMethod<M> = M extends keyof EvntboardModules\n")
+	b.WriteString("\t? (keyof EvntboardModules[M] & string) | (M extends EvntboardOpenModule ? (string & {}) : never)\n")
+	b.WriteString("\t: string;\n")
+	b.WriteString("type EvntboardModuleCall<M, K> = M extends keyof EvntboardModules\n")
+	b.WriteString("\t? K extends keyof EvntboardModules[M] ? EvntboardModules[M][K] : { params: any; result: any }\n")
+	b.WriteString("\t: { params: any; result: any };\n\n")
+
+	b.WriteString(globals)
+
+	return b.String()
+}
+
+var identifierRX = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func quote(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+func propertyName(name string) string {
+	if identifierRX.MatchString(name) {
+		return name
+	}
+	return quote(name)
+}
+
+func orAny(value string) string {
+	if value == "" {
+		return "any"
+	}
+	return value
+}
+
+func indent(value string, depth int) string {
+	return strings.ReplaceAll(value, "\n", "\n"+strings.Repeat("\t", depth))
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_timed_out := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "c7hjx0qe",
+			"name": "timed_out",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_timed_out); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_timed_out)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("c7hjx0qe")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_max_execution_time := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "k2rv8twa",
+			"name": "max_execution_time",
+			"type": "number",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": 0,
+				"max": null,
+				"noDecimal": true
+			}
+		}`), new_max_execution_time); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_max_execution_time)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("k2rv8twa")
+
+		return dao.SaveCollection(collection)
+	})
+}
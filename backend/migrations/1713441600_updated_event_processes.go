@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_cancelled := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "w3ncx8ku",
+			"name": "cancelled",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_cancelled); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_cancelled)
+
+		// add
+		new_cancelled_by := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "pl0e6z2r",
+			"name": "cancelled_by",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "_pb_users_auth_",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_cancelled_by); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_cancelled_by)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("w3ncx8ku")
+
+		// remove
+		collection.Schema.RemoveField("pl0e6z2r")
+
+		return dao.SaveCollection(collection)
+	})
+}
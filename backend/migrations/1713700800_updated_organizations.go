@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sy0qvvpo60siidq")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_http_allowed_hosts := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "s4kd9wzh",
+			"name": "http_allowed_hosts",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_http_allowed_hosts); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_http_allowed_hosts)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sy0qvvpo60siidq")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("s4kd9wzh")
+
+		return dao.SaveCollection(collection)
+	})
+}
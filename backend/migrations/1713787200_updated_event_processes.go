@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_replay := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "r8yq3pnx",
+			"name": "replay",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_replay); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_replay)
+
+		// add
+		new_replay_of := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "o5ce2vjk",
+			"name": "replay_of",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "k6am2xon4a97e8a",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_replay_of); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_replay_of)
+
+		// add
+		new_dry_run := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "d3mw7zah",
+			"name": "dry_run",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_dry_run); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_dry_run)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("k6am2xon4a97e8a")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("r8yq3pnx")
+
+		// remove
+		collection.Schema.RemoveField("o5ce2vjk")
+
+		// remove
+		collection.Schema.RemoveField("d3mw7zah")
+
+		return dao.SaveCollection(collection)
+	})
+}
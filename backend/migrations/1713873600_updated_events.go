@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_emitted_by := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "u7bq2zne",
+			"name": "emitted_by",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "_pb_users_auth_",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_emitted_by); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_emitted_by)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("u7bq2zne")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_schema_errors := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "j7cr4tyn",
+			"name": "schema_errors",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_schema_errors); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_schema_errors)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("j7cr4tyn")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_matcher := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "t6mx3rkq",
+			"name": "matcher",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_matcher); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_matcher)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("t6mx3rkq")
+
+		return dao.SaveCollection(collection)
+	})
+}
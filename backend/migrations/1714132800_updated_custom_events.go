@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("qxm0fmigapp3j5k")
+		if err != nil {
+			return err
+		}
+
+		// update
+		edit_name := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "mfbhtsep",
+			"name": "name",
+			"type": "text",
+			"required": true,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": 4,
+				"max": null,
+				"pattern": "^[a-z_-]+(\\.[a-z_-]+)*$"
+			}
+		}`), edit_name); err != nil {
+			return err
+		}
+		collection.Schema.AddField(edit_name)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("qxm0fmigapp3j5k")
+		if err != nil {
+			return err
+		}
+
+		// update
+		edit_name := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "mfbhtsep",
+			"name": "name",
+			"type": "text",
+			"required": true,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": 4,
+				"max": null,
+				"pattern": "^[a-z_-]+$"
+			}
+		}`), edit_name); err != nil {
+			return err
+		}
+		collection.Schema.AddField(edit_name)
+
+		return dao.SaveCollection(collection)
+	})
+}
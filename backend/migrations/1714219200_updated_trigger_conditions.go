@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_language := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "l3tsm8nb",
+			"name": "language",
+			"type": "select",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSelect": 1,
+				"values": [
+					"javascript",
+					"typescript"
+				]
+			}
+		}`), new_language); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_language)
+
+		// add
+		new_compiled_code := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "c5jsv1cx",
+			"name": "compiled_code",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_compiled_code); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_compiled_code)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("yecumyhdy7tdsi8")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("l3tsm8nb")
+
+		// remove
+		collection.Schema.RemoveField("c5jsv1cx")
+
+		return dao.SaveCollection(collection)
+	})
+}
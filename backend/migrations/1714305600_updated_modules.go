@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_manifest := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "mnf3st7q",
+			"name": "manifest",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_manifest); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_manifest)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("mnf3st7q")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_max_sessions := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "x2nd8rqm",
+			"name": "max_sessions",
+			"type": "number",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": 0,
+				"max": null,
+				"noDecimal": true
+			}
+		}`), new_max_sessions); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_max_sessions)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("x2nd8rqm")
+
+		return dao.SaveCollection(collection)
+	})
+}
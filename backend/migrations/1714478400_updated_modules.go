@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_last_seen_at := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "p5ls8wen",
+			"name": "last_seen_at",
+			"type": "date",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": "",
+				"max": ""
+			}
+		}`), new_last_seen_at); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_last_seen_at)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("p5ls8wen")
+
+		return dao.SaveCollection(collection)
+	})
+}
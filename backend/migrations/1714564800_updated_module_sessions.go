@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("m3ps7kx9dw2qv4c")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_node := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "n8dq4rwz",
+			"name": "node",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_node); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_node)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("m3ps7kx9dw2qv4c")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("n8dq4rwz")
+
+		return dao.SaveCollection(collection)
+	})
+}
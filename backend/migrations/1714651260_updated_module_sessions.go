@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("m3ps7kx9dw2qv4c")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_token := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "t3kw7bdn",
+			"name": "token",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "k2tv9bq7hn4xw8e",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_token); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_token)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("m3ps7kx9dw2qv4c")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("t3kw7bdn")
+
+		return dao.SaveCollection(collection)
+	})
+}
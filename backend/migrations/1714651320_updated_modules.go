@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/evntboard/app/backend/internal/moduletoken"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		tokens, err := dao.FindCollectionByNameOrId("k2tv9bq7hn4xw8e")
+		if err != nil {
+			return err
+		}
+
+		// move the plain tokens to module_tokens, only their hash stay
+		modules, err := dao.FindRecordsByFilter(collection.Id, "token != ''", "", 0, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, module := range modules {
+			token := module.GetString("token")
+
+			record := models.NewRecord(tokens)
+			record.Set("module", module.Id)
+			record.Set("label", "default")
+			record.Set("hash", moduletoken.Hash(token))
+			record.Set("prefix", token[:min(len(token), 6)])
+
+			if err := dao.SaveRecord(record); err != nil {
+				return err
+			}
+		}
+
+		// remove
+		collection.Schema.RemoveField("mkmfuqq5")
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sqj645vi14kmjv7")
+		if err != nil {
+			return err
+		}
+
+		// add
+		del_token := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "mkmfuqq5",
+			"name": "token",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), del_token); err != nil {
+			return err
+		}
+		collection.Schema.AddField(del_token)
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("t5wh9ok2rb7ne4m")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_signature_scheme := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "s8qv3xmt",
+			"name": "signature_scheme",
+			"type": "select",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSelect": 1,
+				"values": [
+					"hmac",
+					"stripe",
+					"twitch"
+				]
+			}
+		}`), new_signature_scheme); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_signature_scheme)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("t5wh9ok2rb7ne4m")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("s8qv3xmt")
+
+		return dao.SaveCollection(collection)
+	})
+}
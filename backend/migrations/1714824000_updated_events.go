@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		json.Unmarshal([]byte(`[
+			"CREATE INDEX ` + "`" + `idx_sEsk4dy` + "`" + ` ON ` + "`" + `events` + "`" + ` (` + "`" + `name` + "`" + `)",
+			"CREATE INDEX ` + "`" + `idx_Ev7qLt3` + "`" + ` ON ` + "`" + `events` + "`" + ` (\n  ` + "`" + `organization` + "`" + `,\n  ` + "`" + `name` + "`" + `,\n  ` + "`" + `created` + "`" + `\n)"
+		]`), &collection.Indexes)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("8l5w6ox66w2yy6t")
+		if err != nil {
+			return err
+		}
+
+		json.Unmarshal([]byte(`[
+			"CREATE INDEX ` + "`" + `idx_sEsk4dy` + "`" + ` ON ` + "`" + `events` + "`" + ` (` + "`" + `name` + "`" + `)"
+		]`), &collection.Indexes)
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// the examples seeded before the imports use the shareds as globals, an
+// untouched copy is moved to import / export like the new organizations
+const (
+	seededUtilsGlobal = "const randomNumber = (min, max) => Math.floor(Math.random() * (max - min + 1) + min);\nconst randomByte = () => randomNumber(0, 255)\nconst randomPercent = () => (randomNumber(50, 100) * 0.01).toFixed(2)\nconst randomCssRgba = () => `rgba(${[randomByte(), randomByte(), randomByte(), randomPercent()].join(',')})`"
+	seededUtilsModule = "export const randomNumber = (min, max) => Math.floor(Math.random() * (max - min + 1) + min);\nconst randomByte = () => randomNumber(0, 255)\nconst randomPercent = () => (randomNumber(50, 100) * 0.01).toFixed(2)\nexport const randomCssRgba = () => `rgba(${[randomByte(), randomByte(), randomByte(), randomPercent()].join(',')})`"
+	seededBtn1Global  = "// module.notify doesn't block the trigger execution\nconst result = module.notify(\n    \"board\",\n    \"updateText\",\n    {\n        \"text\": `${randomNumber(0,100)}`,\n        \"slug\": \"btn-1\"\n    }\n)\n\n// result is always null, module.notify return nothing\nlog('result', result)\n\n// wait for 100 ms\n\nsleep(100)\n\n// module.notify doesn't block the trigger execution\nmodule.notify(\n    \"board\",\n    \"updateColor\",\n    {\n        \"color\": `${randomCssRgba()}`,\n        \"slug\": \"txt-1\"\n    }\n)\n"
+	seededBtn1Module  = "import { randomNumber, randomCssRgba } from './utils'\n\n// module.notify doesn't block the trigger execution\nconst result = module.notify(\n    \"board\",\n    \"updateText\",\n    {\n        \"text\": `${randomNumber(0,100)}`,\n        \"slug\": \"btn-1\"\n    }\n)\n\n// result is always null, module.notify return nothing\nlog('result', result)\n\n// run after 100 ms without blocking the trigger\nsetTimeout(() => {\n    module.notify(\n        \"board\",\n        \"updateColor\",\n        {\n            \"color\": `${randomCssRgba()}`,\n            \"slug\": \"txt-1\"\n        }\n    )\n}, 100)\n"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		return rewriteSeededExamples(db, seededUtilsGlobal, seededUtilsModule, seededBtn1Global, seededBtn1Module)
+	}, func(db dbx.Builder) error {
+		return rewriteSeededExamples(db, seededUtilsModule, seededUtilsGlobal, seededBtn1Module, seededBtn1Global)
+	})
+}
+
+// rewriteSeededExamples only touch a trigger whose utils shared is rewritten
+// as well, a trigger importing from a global shared or the reverse would break
+func rewriteSeededExamples(db dbx.Builder, utilsFrom, utilsTo, btn1From, btn1To string) error {
+	now := types.NowDateTime().String()
+
+	if _, err := db.NewQuery(`
+		UPDATE triggers SET code = {:btn1To}, updated = {:now}
+		WHERE name = '/example/board/btn-1' AND code = {:btn1From}
+		AND EXISTS (
+			SELECT 1 FROM shareds
+			WHERE shareds.organization = triggers.organization
+			AND shareds.name = '/example/board/utils' AND shareds.code = {:utilsFrom}
+		)
+	`).Bind(dbx.Params{
+		"btn1From":  btn1From,
+		"btn1To":    btn1To,
+		"utilsFrom": utilsFrom,
+		"now":       now,
+	}).Execute(); err != nil {
+		return err
+	}
+
+	// a global trigger left in the folder still work with an exporting
+	// shared, its exports are copied to the global scope
+	_, err := db.NewQuery(`
+		UPDATE shareds SET code = {:utilsTo}, updated = {:now}
+		WHERE name = '/example/board/utils' AND code = {:utilsFrom}
+	`).Bind(dbx.Params{
+		"utilsFrom": utilsFrom,
+		"utilsTo":   utilsTo,
+		"now":       now,
+	}).Execute()
+
+	return err
+}
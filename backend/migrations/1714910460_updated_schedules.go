@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("d8kq2m5vxn3tz7r")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_next_run_at := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "t6nr4yhq",
+			"name": "next_run_at",
+			"type": "date",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": "",
+				"max": ""
+			}
+		}`), new_next_run_at); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_next_run_at)
+
+		json.Unmarshal([]byte(`[
+			"CREATE INDEX ` + "`" + `idx_Sc4nRt8` + "`" + ` ON ` + "`" + `schedules` + "`" + ` (\n  ` + "`" + `enable` + "`" + `,\n  ` + "`" + `next_run_at` + "`" + `\n)"
+		]`), &collection.Indexes)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("d8kq2m5vxn3tz7r")
+		if err != nil {
+			return err
+		}
+
+		json.Unmarshal([]byte(`[]`), &collection.Indexes)
+
+		// remove
+		collection.Schema.RemoveField("t6nr4yhq")
+
+		return dao.SaveCollection(collection)
+	})
+}
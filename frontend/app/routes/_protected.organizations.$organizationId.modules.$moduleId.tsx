@@ -1,7 +1,6 @@
 import { ClientResponseError } from 'pocketbase';
 import { ActionFunctionArgs, json, redirect } from '@remix-run/node';
 import { Link, NavLink, Outlet, useLoaderData } from '@remix-run/react';
-import { v4 as uuid } from 'uuid';
 
 import { moduleUpdateFormSchema } from '~/validation/module';
 import { createSession, getPocketbase, getUser } from '~/utils/pb.server';
@@ -92,15 +91,47 @@ export async function action(args: ActionFunctionArgs) {
       }
       break;
     }
+    case 'token': {
+      try {
+        const created = await pb.send(`/api/organization/${organizationId}/modules/${moduleId}/tokens`, {
+          method: 'POST',
+          body: {
+            label: 'default'
+          }
+        });
+        return json({ token: created.token });
+      } catch (e) {
+        if (e instanceof ClientResponseError) {
+          return json({
+            errors: {
+              ...e.data.data,
+              global: {
+                message: e.data.message
+              }
+            }
+          });
+        }
+      }
+      break;
+    }
     case 'refresh': {
       try {
-        await pb.collection(Collections.Modules).update(
-          moduleId,
-          {
-            token: `${uuid()}`
+        // new token first, then revoke the previous ones so the module is never left without one
+        const tokens = await pb.collection(Collections.ModuleTokens).getFullList({
+          filter: `module.id = "${moduleId}" && revoked_at = ""`
+        });
+        const created = await pb.send(`/api/organization/${organizationId}/modules/${moduleId}/tokens`, {
+          method: 'POST',
+          body: {
+            label: 'default'
           }
-        );
-        return null;
+        });
+        for (const token of tokens) {
+          await pb.send(`/api/organization/${organizationId}/modules/${moduleId}/tokens/${token.id}`, {
+            method: 'DELETE'
+          });
+        }
+        return json({ token: created.token });
       } catch (e) {
         if (e instanceof ClientResponseError) {
           return json({
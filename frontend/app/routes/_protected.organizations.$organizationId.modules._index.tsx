@@ -2,7 +2,6 @@ import { ActionFunctionArgs, json, redirect } from '@remix-run/node'
 import { Link, useFetcher, useLoaderData, useRevalidator } from '@remix-run/react'
 import { ColumnDef } from '@tanstack/react-table'
 import { ClientResponseError } from 'pocketbase'
-import { v4 as uuid } from 'uuid'
 
 import { Collections, ModuleParamsResponse, ModulesResponse } from '~/types/pocketbase';
 import { cn } from '~/utils/cn'
@@ -74,7 +73,12 @@ export async function action(args: ActionFunctionArgs) {
           code: result.data.code,
           name: result.data.name,
           sub: result.data.sub,
-          token: `${uuid()}`
+        })
+        await pb.send(`/api/organization/${organizationId}/modules/${created.id}/tokens`, {
+          method: 'POST',
+          body: {
+            label: 'default',
+          },
         })
         return redirect(`/organizations/${organizationId}/modules/${created.id}`)
       } catch (e) {
@@ -174,16 +178,38 @@ export const columns: ColumnDef<ModulesResponse<{ module_params_via_module: Modu
     id: 'actions',
     cell: function ActionsComponent({ row }) {
       const module = row.original
-      const refreshFetcher = useFetcher()
+      const tokenFetcher = useFetcher<{
+        token?: string,
+        errors?: Record<string, { type: string, message: string }>
+      }>()
       const deleteFetcher = useFetcher()
       const [modalDeleteOpen, setModalDeleteOpen] = useState(false)
+      const [modalTokenOpen, setModalTokenOpen] = useState(false)
+
+      useEffect(() => {
+        if (tokenFetcher.state === 'idle' && tokenFetcher.data) {
+          setModalTokenOpen(true)
+        }
+      }, [tokenFetcher.state, tokenFetcher.data])
 
       const handleOpenDelete = () => {
         setModalDeleteOpen(true)
       }
 
+      // the plain token is only returned once, on creation
+      const handleNewToken = () => {
+        tokenFetcher.submit({
+            _action: 'token',
+          },
+          {
+            action: `/organizations/${module.organization}/modules/${module.id}`,
+            method: 'POST',
+          },
+        )
+      }
+
       const handleRefreshToken = () => {
-        refreshFetcher.submit({
+        tokenFetcher.submit({
             _action: 'refresh',
           },
           {
@@ -195,7 +221,7 @@ export const columns: ColumnDef<ModulesResponse<{ module_params_via_module: Modu
 
       const handleCopyToken = async () => {
         try {
-          await navigator.clipboard.writeText(module.token)
+          await navigator.clipboard.writeText(tokenFetcher.data?.token ?? '')
         } catch (err) {
           console.error('Failed to copy: ', err)
         }
@@ -219,6 +245,24 @@ export const columns: ColumnDef<ModulesResponse<{ module_params_via_module: Modu
               </DialogHeader>
             </DialogContent>
           </Dialog>
+          <Dialog open={modalTokenOpen} onOpenChange={setModalTokenOpen}>
+            <DialogContent>
+              <DialogHeader>
+                <DialogTitle>Token for [{row.original.code}] {row.original.name} module</DialogTitle>
+                <DialogDescription className={cn('break-all', { 'text-destructive': !tokenFetcher.data?.token })}>
+                  {tokenFetcher.data?.token ?? tokenFetcher.data?.errors?.global?.message}
+                </DialogDescription>
+                <DialogFooter>
+                  {tokenFetcher.data?.token && (
+                    <Button onClick={handleCopyToken} className="flex gap-2">
+                      <Icons.token className="h-4 w-4" />
+                      Copy token
+                    </Button>
+                  )}
+                </DialogFooter>
+              </DialogHeader>
+            </DialogContent>
+          </Dialog>
           <DropdownMenu>
             <DropdownMenuTrigger asChild>
               <Button variant="ghost" className="h-8 w-8 p-0">
@@ -237,10 +281,10 @@ export const columns: ColumnDef<ModulesResponse<{ module_params_via_module: Modu
               </DropdownMenuItem>
               <DropdownMenuItem
                 className="flex gap-2 cursor-pointer"
-                onClick={handleCopyToken}
+                onClick={handleNewToken}
               >
                 <Icons.token className="h-4 w-4" />
-                Copy token
+                New token
               </DropdownMenuItem>
               <DropdownMenuItem
                 className="flex gap-2 cursor-pointer"
@@ -249,7 +293,7 @@ export const columns: ColumnDef<ModulesResponse<{ module_params_via_module: Modu
                 <Icons.refresh className="h-4 w-4" />
                 Refresh token
                 <Icons.loader
-                  className={cn('animate-spin', { hidden: refreshFetcher.state === 'idle' })}
+                  className={cn('animate-spin', { hidden: tokenFetcher.state === 'idle' })}
                 />
               </DropdownMenuItem>
               <DropdownMenuSeparator />
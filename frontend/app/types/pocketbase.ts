@@ -12,6 +12,7 @@ export enum Collections {
 	EventProcesses = "event_processes",
 	Events = "events",
 	ModuleParams = "module_params",
+	ModuleTokens = "module_tokens",
 	Modules = "modules",
 	Organizations = "organizations",
 	Shareds = "shareds",
@@ -94,13 +95,28 @@ export type ModuleParamsRecord<Tvalue = unknown> = {
 	value?: null | Tvalue
 }
 
+export enum ModuleTokensMethodsOptions {
+	"event.new" = "event.new",
+	"storage.get" = "storage.get",
+	"storage.set" = "storage.set",
+}
+export type ModuleTokensRecord<Tevents = unknown> = {
+	events?: null | Tevents
+	expires_at?: IsoDateString
+	hash: string
+	label?: string
+	methods?: ModuleTokensMethodsOptions[]
+	module: RecordIdString
+	prefix?: string
+	revoked_at?: IsoDateString
+}
+
 export type ModulesRecord = {
 	code: string
 	name: string
 	organization: RecordIdString
 	session?: string
 	sub?: string
-	token?: string
 }
 
 export type OrganizationsRecord = {
@@ -166,6 +182,7 @@ export type EventProcessRequestsResponse<Terror = unknown, Tparams = unknown, Tr
 export type EventProcessesResponse<Texpand = unknown> = Required<EventProcessesRecord> & BaseSystemFields<Texpand>
 export type EventsResponse<Tpayload = unknown, Texpand = unknown> = Required<EventsRecord<Tpayload>> & BaseSystemFields<Texpand>
 export type ModuleParamsResponse<Tvalue = unknown, Texpand = unknown> = Required<ModuleParamsRecord<Tvalue>> & BaseSystemFields<Texpand>
+export type ModuleTokensResponse<Tevents = unknown, Texpand = unknown> = Required<ModuleTokensRecord<Tevents>> & BaseSystemFields<Texpand>
 export type ModulesResponse<Texpand = unknown> = Required<ModulesRecord> & BaseSystemFields<Texpand>
 export type OrganizationsResponse<Texpand = unknown> = Required<OrganizationsRecord> & BaseSystemFields<Texpand>
 export type SharedsResponse<Texpand = unknown> = Required<SharedsRecord> & BaseSystemFields<Texpand>
@@ -184,6 +201,7 @@ export type CollectionRecords = {
 	event_processes: EventProcessesRecord
 	events: EventsRecord
 	module_params: ModuleParamsRecord
+	module_tokens: ModuleTokensRecord
 	modules: ModulesRecord
 	organizations: OrganizationsRecord
 	shareds: SharedsRecord
@@ -201,6 +219,7 @@ export type CollectionResponses = {
 	event_processes: EventProcessesResponse
 	events: EventsResponse
 	module_params: ModuleParamsResponse
+	module_tokens: ModuleTokensResponse
 	modules: ModulesResponse
 	organizations: OrganizationsResponse
 	shareds: SharedsResponse
@@ -221,6 +240,7 @@ export type TypedPocketBase = PocketBase & {
 	collection(idOrName: 'event_processes'): RecordService<EventProcessesResponse>
 	collection(idOrName: 'events'): RecordService<EventsResponse>
 	collection(idOrName: 'module_params'): RecordService<ModuleParamsResponse>
+	collection(idOrName: 'module_tokens'): RecordService<ModuleTokensResponse>
 	collection(idOrName: 'modules'): RecordService<ModulesResponse>
 	collection(idOrName: 'organizations'): RecordService<OrganizationsResponse>
 	collection(idOrName: 'shareds'): RecordService<SharedsResponse>